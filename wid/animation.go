@@ -10,14 +10,17 @@ import (
 // VisibilityAnimation holds the animation state for animations that transition between a
 // "visible" and "invisible" state for a fixed duration of time.
 type VisibilityAnimation struct {
-	// How long does the animation last
+	// How long does the animation last. Zero shows and hides immediately.
 	time.Duration
 	State   VisibilityAnimationState
 	Started time.Time
+	// Easing is the curve used while appearing and disappearing. Nil is linear.
+	Easing Easing
 }
 
 // Revealed returns the fraction of the animated entity that should be revealed at the current
-// time in the animation. This fraction is computed with linear interpolation.
+// time in the animation. This fraction is computed with the Easing curve, or with linear
+// interpolation when it is nil.
 //
 // Revealed should be invoked during every frame that v.Animating() returns true.
 //
@@ -31,10 +34,11 @@ func (v *VisibilityAnimation) Revealed(gtx C) float32 {
 	if v.Animating() {
 		op.InvalidateOp{}.Add(gtx.Ops)
 	}
-	if v.Duration == time.Duration(0) {
-		v.Duration = time.Second
+	// A zero duration shows or hides immediately
+	progress := float32(1)
+	if v.Duration > 0 {
+		progress = float32(gtx.Now.Sub(v.Started).Milliseconds()) / float32(v.Milliseconds())
 	}
-	progress := float32(gtx.Now.Sub(v.Started).Milliseconds()) / float32(v.Milliseconds())
 	if progress >= 1 {
 		if v.State == Appearing {
 			v.State = Visible
@@ -42,6 +46,9 @@ func (v *VisibilityAnimation) Revealed(gtx C) float32 {
 			v.State = Invisible
 		}
 	}
+	if v.Easing != nil && v.Animating() {
+		progress = v.Easing(progress)
+	}
 	switch v.State {
 	case Visible:
 		return 1
@@ -145,6 +152,8 @@ func (v VisibilityAnimationState) String() string {
 //
 // Update method must be called every tick to HandleEvents the progress value.
 type Progress struct {
+	// Easing is the curve applied to the progress. Nil is linear.
+	Easing    Easing
 	progress  float32
 	duration  time.Duration
 	began     time.Time
@@ -228,11 +237,15 @@ func (p *Progress) Update(now time.Time) {
 		elapsed = now.Sub(p.began).Milliseconds()
 		total   = p.duration.Milliseconds()
 	)
+	t := float32(elapsed) / float32(total)
+	if p.Easing != nil && t < 1 {
+		t = p.Easing(t)
+	}
 	switch p.direction {
 	case Forward:
-		p.progress = float32(elapsed) / float32(total)
+		p.progress = t
 	case Reverse:
-		p.progress = 1 - float32(elapsed)/float32(total)
+		p.progress = 1 - t
 	}
 }
 
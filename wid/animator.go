@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"gioui.org/op"
+)
+
+// Easing maps the linear progress of an animation, from 0 to 1, to the eased progress.
+// The result starts at 0 and ends at 1, but can go outside this range, as a spring does.
+type Easing func(t float32) float32
+
+// Animatable is the types that an Animator can animate
+type Animatable interface {
+	float32 | image.Point | color.NRGBA
+}
+
+// Animator animates a value from its current value to a target over a duration.
+// Call Animate() in every frame. It returns the current value, and requests new frames
+// until the animation is done. The zero value uses EaseInOut over 200ms.
+type Animator[T Animatable] struct {
+	Duration time.Duration
+	Easing   Easing
+	from     T
+	to       T
+	started  time.Time
+	running  bool
+	init     bool
+}
+
+// Standard easing curves, from the CSS specification
+var (
+	Linear    Easing = func(t float32) float32 { return t }
+	EaseIn           = CubicBezier(0.42, 0, 1, 1)
+	EaseOut          = CubicBezier(0, 0, 0.58, 1)
+	EaseInOut        = CubicBezier(0.42, 0, 0.58, 1)
+	// Emphasized is the standard easing used by Material 3
+	Emphasized = CubicBezier(0.2, 0, 0, 1)
+)
+
+const defaultAnimationDuration = 200 * time.Millisecond
+
+// NewAnimator returns an animator starting at value
+func NewAnimator[T Animatable](value T, duration time.Duration, easing Easing) *Animator[T] {
+	return &Animator[T]{Duration: duration, Easing: easing, from: value, to: value, init: true}
+}
+
+// CubicBezier returns the easing curve going from (0,0) to (1,1) with the control
+// points (x1,y1) and (x2,y2), like the CSS cubic-bezier() function. x1 and x2 must be
+// between 0 and 1.
+func CubicBezier(x1, y1, x2, y2 float32) Easing {
+	bezier := func(a, b, t float32) float32 {
+		return 3*a*(1-t)*(1-t)*t + 3*b*(1-t)*t*t + t*t*t
+	}
+	return func(x float32) float32 {
+		if x <= 0 || x >= 1 {
+			return Clamp(x, 0, 1)
+		}
+		// Find t where the curve passes x, by bisection, as x(t) is increasing
+		lo, hi := float32(0), float32(1)
+		t := x
+		for i := 0; i < 24; i++ {
+			if bezier(x1, x2, t) < x {
+				lo = t
+			} else {
+				hi = t
+			}
+			t = (lo + hi) / 2
+		}
+		return bezier(y1, y2, t)
+	}
+}
+
+// Spring returns an easing curve that behaves like a spring settling at the end of the
+// animation. damping is the damping ratio: below 1 the value overshoots and bounces,
+// at 1 or above it settles without bouncing.
+func Spring(damping float32) Easing {
+	damping = Max(damping, 0.05)
+	// The frequency is chosen so that the amplitude is down to 0.1% at the end
+	w := 6.9 / float64(Min(damping, 1))
+	z := float64(damping)
+	return func(t float32) float32 {
+		if t <= 0 {
+			return 0
+		}
+		if t >= 1 {
+			return 1
+		}
+		x := float64(t)
+		if z >= 1 {
+			return float32(1 - (1+w*x)*math.Exp(-w*x))
+		}
+		wd := w * math.Sqrt(1-z*z)
+		return float32(1 - math.Exp(-z*w*x)*(math.Cos(wd*x)+z*w/wd*math.Sin(wd*x)))
+	}
+}
+
+// Lerp interpolates linearly between a and b. Colors are interpolated with Interpolate(),
+// with t limited to 0..1.
+func Lerp[T Animatable](a, b T, t float32) T {
+	var v any
+	switch a := any(a).(type) {
+	case float32:
+		v = a + (any(b).(float32)-a)*t
+	case image.Point:
+		d := any(b).(image.Point).Sub(a)
+		v = a.Add(image.Pt(int(math.Round(float64(float32(d.X)*t))), int(math.Round(float64(float32(d.Y)*t)))))
+	case color.NRGBA:
+		v = Interpolate(a, any(b).(color.NRGBA), Clamp(t, 0, 1))
+	}
+	return v.(T)
+}
+
+// Animate sets the target of the animation and returns the current value. When the target
+// changes, a new animation starts from the current value. The first call sets the value
+// without animation. Animate requests a new frame while the animation is running.
+func (a *Animator[T]) Animate(gtx C, target T) T {
+	if !a.init {
+		a.Set(target)
+	} else if target != a.to {
+		a.Start(gtx.Now, a.value(gtx.Now), target)
+	}
+	return a.Value(gtx)
+}
+
+// Start begins an animation from one value to another at the given time
+func (a *Animator[T]) Start(now time.Time, from, to T) {
+	a.from, a.to = from, to
+	a.started = now
+	a.running = true
+	a.init = true
+}
+
+// Set changes the value immediately, stopping any animation
+func (a *Animator[T]) Set(value T) {
+	a.from, a.to = value, value
+	a.running = false
+	a.init = true
+}
+
+// Value returns the current value, and requests a new frame while the animation is running
+func (a *Animator[T]) Value(gtx C) T {
+	v := a.value(gtx.Now)
+	if a.running {
+		op.InvalidateOp{}.Add(gtx.Ops)
+	}
+	return v
+}
+
+// Target returns the value that the animation ends at
+func (a *Animator[T]) Target() T {
+	return a.to
+}
+
+// Running returns true while the value is changing
+func (a *Animator[T]) Running() bool {
+	return a.running
+}
+
+// value returns the value at the given time, and stops the animation when it is done
+func (a *Animator[T]) value(now time.Time) T {
+	if !a.running {
+		return a.to
+	}
+	d := a.Duration
+	if d <= 0 {
+		d = defaultAnimationDuration
+	}
+	t := float32(now.Sub(a.started)) / float32(d)
+	if t >= 1 {
+		a.running = false
+		return a.to
+	}
+	easing := a.Easing
+	if easing == nil {
+		easing = EaseInOut
+	}
+	return Lerp(a.from, a.to, easing(Max(t, 0)))
+}
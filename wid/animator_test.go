@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestEasingEndpoints(t *testing.T) {
+	// All curves go from 0 to 1. The curves, except Linear, limit values outside 0..1.
+	tests := []struct {
+		name   string
+		e      Easing
+		limits bool
+	}{
+		{"Linear", Linear, false},
+		{"EaseIn", EaseIn, true},
+		{"EaseOut", EaseOut, true},
+		{"EaseInOut", EaseInOut, true},
+		{"Emphasized", Emphasized, true},
+		{"Spring 0.3", Spring(0.3), true},
+		{"Spring 1", Spring(1), true},
+		{"Spring 2", Spring(2), true},
+	}
+	for _, tt := range tests {
+		for _, x := range []float32{-1, 0, 1, 2} {
+			want := Clamp(x, 0, 1)
+			if !tt.limits {
+				want = x
+			}
+			if got := tt.e(x); got != want {
+				t.Errorf("%s(%g) = %g, want %g", tt.name, x, got, want)
+			}
+		}
+	}
+}
+
+func TestEasingMonotonic(t *testing.T) {
+	tests := []struct {
+		name string
+		e    Easing
+	}{
+		{"EaseIn", EaseIn},
+		{"EaseOut", EaseOut},
+		{"EaseInOut", EaseInOut},
+		{"Emphasized", Emphasized},
+		{"Spring 1", Spring(1)},
+		{"Spring 2", Spring(2)},
+	}
+	for _, tt := range tests {
+		prev := float32(0)
+		for i := 1; i <= 100; i++ {
+			v := tt.e(float32(i) / 100)
+			if v < prev-1e-5 || v > 1+1e-5 {
+				t.Errorf("%s is not increasing in 0..1 at %d%%: %g after %g", tt.name, i, v, prev)
+				break
+			}
+			prev = v
+		}
+	}
+}
+
+func TestCubicBezierValues(t *testing.T) {
+	tests := []struct {
+		name string
+		e    Easing
+		x    float32
+		want float32
+	}{
+		{"linear curve", CubicBezier(0.25, 0.25, 0.75, 0.75), 0.3, 0.3},
+		{"EaseInOut middle", EaseInOut, 0.5, 0.5},
+		{"EaseIn slow start", EaseIn, 0.25, 0.093},
+		{"EaseOut fast start", EaseOut, 0.25, 0.378},
+	}
+	for _, tt := range tests {
+		if got := tt.e(tt.x); math.Abs(float64(got-tt.want)) > 0.002 {
+			t.Errorf("%s: got %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpringOvershoot(t *testing.T) {
+	max := func(e Easing) float32 {
+		m := float32(0)
+		for i := 0; i <= 1000; i++ {
+			m = Max(m, e(float32(i)/1000))
+		}
+		return m
+	}
+	if m := max(Spring(0.3)); m <= 1 {
+		t.Errorf("underdamped spring does not overshoot, max %g", m)
+	}
+	if m := max(Spring(1)); m > 1 {
+		t.Errorf("critically damped spring overshoots, max %g", m)
+	}
+}
+
+func TestLerp(t *testing.T) {
+	if got := Lerp(float32(2), 4, 0.25); got != 2.5 {
+		t.Errorf("float32: got %g", got)
+	}
+	if got := Lerp(image.Pt(0, 10), image.Pt(10, 0), 0.5); got != image.Pt(5, 5) {
+		t.Errorf("point: got %v", got)
+	}
+	a, b := color.NRGBA{A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	if got := Lerp(a, b, 2); got != b {
+		t.Errorf("color is not limited to the target: got %v", got)
+	}
+}
+
+func TestAnimator(t *testing.T) {
+	var ops op.Ops
+	start := time.Unix(1000, 0)
+	gtx := layout.Context{Ops: &ops, Now: start}
+	a := Animator[float32]{Duration: time.Second, Easing: Linear}
+	if v := a.Animate(gtx, 10); v != 10 || a.Running() {
+		t.Fatalf("first value is animated: %g", v)
+	}
+	if v := a.Animate(gtx, 20); v != 10 || !a.Running() {
+		t.Fatalf("animation did not start at the current value: %g", v)
+	}
+	gtx.Now = start.Add(500 * time.Millisecond)
+	if v := a.Animate(gtx, 20); v != 15 {
+		t.Errorf("halfway: got %g, want 15", v)
+	}
+	// A new target starts from the current value
+	a.Animate(gtx, 0)
+	gtx.Now = start.Add(1000 * time.Millisecond)
+	if v := a.Value(gtx); v != 7.5 {
+		t.Errorf("retargeted: got %g, want 7.5", v)
+	}
+	gtx.Now = start.Add(1500 * time.Millisecond)
+	if v := a.Value(gtx); v != 0 || a.Running() {
+		t.Errorf("end: got %g, running %v", v, a.Running())
+	}
+}
+
+func TestRevealedZeroDuration(t *testing.T) {
+	var ops op.Ops
+	gtx := layout.Context{Ops: &ops, Now: time.Unix(1000, 0)}
+	v := VisibilityAnimation{State: Invisible}
+	v.Appear(gtx.Now)
+	if r := v.Revealed(gtx); r != 1 || v.State != Visible {
+		t.Errorf("appear: revealed %g, state %v", r, v.State)
+	}
+	v.Disappear(gtx.Now)
+	if r := v.Revealed(gtx); r != 0 || v.State != Invisible {
+		t.Errorf("disappear: revealed %g, state %v", r, v.State)
+	}
+}
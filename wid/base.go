@@ -7,6 +7,7 @@ import (
 	"image"
 	"image/color"
 	"sync"
+	"time"
 
 	"golang.org/x/exp/constraints"
 
@@ -62,6 +63,7 @@ type Base struct {
 	description  string
 	Font         *text.Font
 	FontSize     float32
+	tabIndex     int
 }
 
 // BaseIf is the interface functions for widgets, used by options to set parameters
@@ -77,6 +79,7 @@ type BaseIf interface {
 	setDisabler(b *bool)
 	getTheme() *Theme
 	setFontSize(f float32)
+	setTabIndex(i int)
 }
 
 // BaseOption is a type for optional parameters when creating widgets
@@ -139,6 +142,10 @@ func (wid *Base) setFontSize(h float32) {
 	wid.FontSize = h
 }
 
+func (wid *Base) setTabIndex(i int) {
+	wid.tabIndex = i
+}
+
 func (wid *Base) setDisabler(b *bool) {
 	wid.disabler = b
 }
@@ -344,7 +351,7 @@ func CalcMin(gtx C, width unit.Dp) image.Point {
 func UpdateMousePos(gtx C, win *app.Window) {
 	eventArea := clip.Rect(image.Rect(0, 0, 99999, 99999)).Push(gtx.Ops)
 	pointer.InputOp{
-		Types: pointer.Move,
+		Types: pointer.Move | pointer.Press | pointer.Drag | pointer.Release,
 		Tag:   win,
 	}.Add(gtx.Ops)
 	eventArea.Pop()
@@ -353,12 +360,51 @@ func UpdateMousePos(gtx C, win *app.Window) {
 		case pointer.Event:
 			MouseX = e.Position.X
 			MouseY = e.Position.Y
+			logPointer(e)
 		}
 	}
 }
 
+// pointerRecord is a pointer event seen by UpdateMousePos, with the position in the window
+type pointerRecord struct {
+	id   pointer.ID
+	time time.Duration
+	pos  image.Point
+	set  bool
+}
+
+var (
+	// pointerLog is the last pointer events seen by UpdateMousePos, in a ring buffer.
+	// It is only used while laying out.
+	pointerLog  [32]pointerRecord
+	pointerNext int
+)
+
+// logPointer saves the window position of a pointer event
+func logPointer(e pointer.Event) {
+	pointerLog[pointerNext] = pointerRecord{id: e.PointerID, time: e.Time, pos: e.Position.Round(), set: true}
+	pointerNext = (pointerNext + 1) % len(pointerLog)
+}
+
+// windowPos returns the window position of the pointer event with the given pointer
+// and time. A widget that got the same event finds its own position in the window
+// by subtracting the position in the event. False is returned for unknown events.
+func windowPos(id pointer.ID, t time.Duration) (image.Point, bool) {
+	for _, r := range pointerLog {
+		if r.set && r.id == id && r.time == t {
+			return r.pos, true
+		}
+	}
+	return image.Point{}, false
+}
+
+// Invalidate will request a new frame. It can be called from any goroutine,
+// including event handlers, and never blocks.
 func Invalidate() {
-	invalidate <- struct{}{}
+	select {
+	case invalidate <- struct{}{}:
+	default:
+	}
 }
 
 func Run(win *app.Window, form *layout.Widget, th *Theme) {
@@ -366,7 +412,8 @@ func Run(win *app.Window, form *layout.Widget, th *Theme) {
 }
 
 func RunWithContext(ctx context.Context, win *app.Window, form *layout.Widget, th *Theme) {
-	invalidate = make(chan struct{})
+	invalidate = make(chan struct{}, 1)
+	addPaletteShortcut(th)
 	for {
 		select {
 		case <-ctx.Done():
@@ -383,6 +430,7 @@ func RunWithContext(ctx context.Context, win *app.Window, form *layout.Widget, t
 				gtx := layout.NewContext(&ops, e)
 				CurrentY = 0
 				CurrentY = 0
+				th.animateTransition(gtx)
 				paint.ColorOp{Color: th.Bg(Surface)}.Add(gtx.Ops)
 				paint.PaintOp{}.Add(gtx.Ops)
 
@@ -390,7 +438,18 @@ func RunWithContext(ctx context.Context, win *app.Window, form *layout.Widget, t
 				GuiLock.Lock()
 				mainForm := *form
 				GuiLock.Unlock()
-				mainForm(gtx)
+				fgtx := gtx
+				if modalOpen() {
+					// The form and the shortcuts are disabled while a dialog is shown
+					fgtx.Queue = nil
+				} else if gtx.Queue != nil {
+					fgtx.Queue = shortcutQueue{gtx.Queue}
+				}
+				handleShortcuts(fgtx)
+				handleFocus(fgtx)
+				mainForm(fgtx)
+				layoutDialogs(gtx)
+				layoutNotifications(gtx, th)
 
 				// A hack to fetch mouse position and window size so we can avoid
 				// tooltips going outside the main window area
@@ -428,3 +487,10 @@ func Clamp[T constraints.Ordered](v T, lo T, hi T) T {
 	}
 	return v
 }
+
+func Abs[T constraints.Signed | constraints.Float](x T) T {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
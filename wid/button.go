@@ -108,10 +108,10 @@ func aButton[V StrValue](style ButtonStyle, th *Theme, label V, options ...Optio
 	b.padding = th.ButtonPadding
 	b.FontSize = 1.0
 	b.cornerRadius = th.ButtonCornerRadius
+	b.Tooltip = PlatformTooltip(th)
 	for _, option := range options {
 		option.apply(&b)
 	}
-	b.Tooltip = PlatformTooltip(th)
 	return &b
 }
 
@@ -137,6 +137,7 @@ func (b *ButtonDef) Layout(gtx C) D {
 			b.HandleClick()
 			dims := b.layout(gtx)
 			b.SetupEventHandlers(gtx, dims.Size)
+			registerFocus(gtx, b, b.tabIndex)
 			dims = b.Tooltip.Layout(gtx, b.hint, func(gtx C) D {
 				return dims
 			})
@@ -181,10 +182,9 @@ func (b *ButtonDef) layout(gtx C) D {
 
 	outline := image.Rect(0, 0, width, height)
 
-	// Draw shadow if pressed. Must be done before clipping
-	// because the shadow is outside the button
+	// Draw the focus ring before clipping, because it is outside the button
 	if b.Clickable.Focused() {
-		DrawShadow(gtx, outline, rr, 20)
+		paintFocusRing(gtx, b.th, outline, rr)
 	}
 	defer clip.UniformRRect(outline, rr).Push(gtx.Ops).Pop()
 
@@ -315,9 +315,8 @@ func drawInk(gtx C, c Press) {
 	}
 	// Twice the speed to attain fully faded in at 0.5.
 	t2 := alphat * 2
-	// Beziér ease-in curve.
-	alphaBezier := t2 * t2 * (3.0 - 2.0*t2)
-	sizeBezier := sizet * sizet * (3.0 - 2.0*sizet)
+	alphaBezier := EaseInOut(t2)
+	sizeBezier := EaseOut(sizet)
 	size := gtx.Constraints.Min.X
 	if h := gtx.Constraints.Min.Y; h > size {
 		size = h
@@ -24,6 +24,7 @@ type Clickable struct {
 	focused    bool
 	pressed    bool
 	index      *int
+	request    focusRequest
 }
 
 // Click represents a click.
@@ -82,6 +83,16 @@ func (b *Clickable) Focused() bool {
 	return b.focused
 }
 
+// Focus moves the keyboard focus to b when it is drawn next time.
+func (b *Clickable) Focus() {
+	b.request.set(focusRequested)
+}
+
+// Blur removes the keyboard focus from b.
+func (b *Clickable) Blur() {
+	b.request.set(blurRequested)
+}
+
 // Clicks returns and clear the clicks since the last call to Clicks.
 func (b *Clickable) Clicks() []Click {
 	clicks := b.clicks
@@ -107,6 +118,7 @@ func (b *Clickable) SetupEventHandlers(gtx C, size image.Point) {
 			keys = key.Set("⏎|Space|←|→|↑|↓")
 		}
 		key.InputOp{Tag: &b.keyTag, Keys: keys}.Add(gtx.Ops)
+		b.request.apply(gtx, &b.keyTag, b.focused)
 	} else {
 		b.focused = false
 	}
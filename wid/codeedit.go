@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/widget"
+)
+
+// CodeEditDef is a multi-line editor for source code and config files.
+// It adds line numbers, syntax highlighting, auto-indent and bracket matching to EditDef.
+type CodeEditDef struct {
+	EditDef
+	lexer     Lexer
+	indent    string
+	src       []rune
+	text      string
+	tokens    []Token
+	regions   []widget.Region
+	lastLen   int
+	lastCaret int
+}
+
+// CodeOption is options specific to code edits
+type CodeOption func(w *CodeEditDef)
+
+var monoFont = text.Font{Variant: "Mono"}
+
+// CodeEdit returns a multi-line editor with syntax highlighting given by lexer.
+// The lexer can be nil for plain text. Edit options like Var() and Area() can be used.
+func CodeEdit(th *Theme, lexer Lexer, options ...Option) layout.Widget {
+	c := new(CodeEditDef)
+	c.setDefaults(th)
+	c.SingleLine = false
+	c.Font = &monoFont
+	c.lexer = lexer
+	c.indent = "\t"
+	for _, option := range options {
+		if o, ok := option.(CodeOption); ok {
+			o(c)
+		} else {
+			option.apply(&c.EditDef)
+		}
+	}
+	if c.value != nil {
+		c.Editor.SetText(*c.value)
+	}
+	c.lastLen = c.Len()
+	c.painter = c.paintCode
+	return c.Layout
+}
+
+// Indent sets the string inserted for each indent level after an opening bracket.
+func Indent(s string) CodeOption {
+	return func(c *CodeEditDef) {
+		c.indent = s
+	}
+}
+
+// Syntax sets the lexer used for highlighting.
+func Syntax(lexer Lexer) CodeOption {
+	return func(c *CodeEditDef) {
+		c.lexer = lexer
+	}
+}
+
+func (o CodeOption) apply(cfg interface{}) {
+	if c, ok := cfg.(*CodeEditDef); ok {
+		o(c)
+	}
+}
+
+// CodeColor returns the highlight color for a given kind of token, derived from the pallet.
+func (th *Theme) CodeColor(kind TokenKind) color.NRGBA {
+	tone := 40
+	if th.DarkMode {
+		tone = 80
+	}
+	switch kind {
+	case TokKeyword:
+		return Tone(th.Pallet.PrimaryColor, tone)
+	case TokType:
+		return Tone(th.Pallet.SecondaryColor, tone)
+	case TokString:
+		return Tone(th.Pallet.TertiaryColor, tone)
+	case TokNumber:
+		return Tone(th.Pallet.ErrorColor, tone)
+	case TokComment:
+		return Tone(th.Pallet.NeutralColor, 60)
+	case TokKey:
+		return Tone(th.Pallet.PrimaryColor, tone-10)
+	case TokPunct:
+		return Tone(th.Pallet.NeutralVariantColor, tone-10)
+	}
+	return th.Fg(Canvas)
+}
+
+// Layout draws the gutter with line numbers to the left of the edit area
+func (c *CodeEditDef) Layout(gtx C) D {
+	c.update()
+	lines := strings.Count(c.text, "\n") + 1
+	digits := Max(3, len(strconv.Itoa(lines)))
+	charWidth := gtx.Sp(c.th.TextSize * 0.6)
+	gutterWidth := (digits+1)*charWidth + gtx.Dp(c.padding.Left)
+
+	macro := op.Record(gtx.Ops)
+	cgtx := gtx
+	cgtx.Constraints.Min.X = Max(0, cgtx.Constraints.Min.X-gutterWidth)
+	cgtx.Constraints.Max.X = Max(0, cgtx.Constraints.Max.X-gutterWidth)
+	dims := c.EditDef.Layout(cgtx)
+	call := macro.Stop()
+
+	c.update()
+	c.autoIndent(gtx)
+
+	// Draw line numbers, aligned with the first visible row of each line
+	gutter := image.Rect(0, 0, gutterWidth, dims.Size.Y)
+	cl := clip.Rect(gutter).Push(gtx.Ops)
+	paint.Fill(gtx.Ops, c.th.Bg(SurfaceVariant))
+	caret, _ := c.Selection()
+	caretLine := strings.Count(string(c.src[:Min(caret, len(c.src))]), "\n")
+	top := gtx.Dp(c.padding.Top + c.th.InsidePadding.Top)
+	lgtx := gtx
+	lgtx.Constraints.Min.X = gutterWidth - charWidth/2
+	lgtx.Constraints.Max.X = lgtx.Constraints.Min.X
+	lgtx.Constraints.Min.Y = 0
+	lineStart := 0
+	for line := 0; line < lines; line++ {
+		c.regions = c.Regions(lineStart, lineStart, c.regions)
+		if len(c.regions) > 0 {
+			col := MulAlpha(c.th.Fg(SurfaceVariant), 120)
+			if line == caretLine {
+				col = c.th.Fg(SurfaceVariant)
+			}
+			o := op.Offset(image.Pt(0, top+c.regions[0].Bounds.Min.Y)).Push(gtx.Ops)
+			paint.ColorOp{Color: col}.Add(gtx.Ops)
+			_ = widget.Label{Alignment: text.End, MaxLines: 1}.Layout(lgtx, c.th.Shaper, *c.Font, c.th.TextSize, strconv.Itoa(line+1))
+			o.Pop()
+		}
+		for lineStart < len(c.src) && c.src[lineStart] != '\n' {
+			lineStart++
+		}
+		lineStart++
+	}
+	cl.Pop()
+
+	defer op.Offset(image.Pt(gutterWidth, 0)).Push(gtx.Ops).Pop()
+	call.Add(gtx.Ops)
+	dims.Size.X += gutterWidth
+	return dims
+}
+
+// update runs the lexer when the text has changed since the last frame
+func (c *CodeEditDef) update() {
+	s := c.Text()
+	if s == c.text && c.src != nil {
+		return
+	}
+	c.text = s
+	c.src = []rune(s)
+	c.tokens = nil
+	if c.lexer != nil {
+		c.tokens = c.lexer.Tokens(c.src)
+	}
+}
+
+// autoIndent copies the indentation of the previous line after a newline is typed.
+// One extra level is added after an opening bracket.
+func (c *CodeEditDef) autoIndent(gtx C) {
+	start, end := c.Selection()
+	if c.Focused() && !c.ReadOnly && start == end && start == c.lastCaret+1 && c.Len() == c.lastLen+1 {
+		c.update()
+		if start > 0 && start <= len(c.src) && c.src[start-1] == '\n' {
+			ls := start - 1
+			for ls > 0 && c.src[ls-1] != '\n' {
+				ls--
+			}
+			ws := scanWhile(c.src, ls, func(r rune) bool { return r == ' ' || r == '\t' })
+			indent := string(c.src[ls:Min(ws, start-1)])
+			last := strings.TrimRight(string(c.src[ls:start-1]), " \t")
+			if strings.HasSuffix(last, "{") || strings.HasSuffix(last, "[") || strings.HasSuffix(last, "(") {
+				indent += c.indent
+			}
+			if indent != "" {
+				c.Insert(indent)
+				op.InvalidateOp{}.Add(gtx.Ops)
+			}
+		}
+	}
+	c.lastLen = c.Len()
+	c.lastCaret, _ = c.Selection()
+}
+
+// paintCode paints the text once for each token kind, clipped to the regions of the tokens.
+func (c *CodeEditDef) paintCode(gtx C) {
+	c.update()
+	c.paintBrackets(gtx)
+	var rects [TokPunct + 1][]image.Rectangle
+	addRegions := func(kind TokenKind, start, end int) {
+		if start >= end {
+			return
+		}
+		c.regions = c.Regions(start, end, c.regions)
+		for _, r := range c.regions {
+			rects[kind] = append(rects[kind], r.Bounds)
+		}
+	}
+	pos := 0
+	for _, t := range c.tokens {
+		addRegions(TokText, pos, t.Start)
+		addRegions(t.Kind, t.Start, t.End)
+		pos = t.End
+	}
+	addRegions(TokText, pos, len(c.src))
+	for kind := range rects {
+		if len(rects[kind]) == 0 {
+			continue
+		}
+		var p clip.Path
+		p.Begin(gtx.Ops)
+		for _, b := range rects[kind] {
+			p.MoveTo(FPt(b.Min))
+			p.LineTo(FPt(image.Pt(b.Max.X, b.Min.Y)))
+			p.LineTo(FPt(b.Max))
+			p.LineTo(FPt(image.Pt(b.Min.X, b.Max.Y)))
+			p.Close()
+		}
+		cl := clip.Outline{Path: p.End()}.Op().Push(gtx.Ops)
+		paint.ColorOp{Color: c.th.CodeColor(TokenKind(kind))}.Add(gtx.Ops)
+		c.Editor.PaintText(gtx)
+		cl.Pop()
+	}
+}
+
+// paintBrackets highlights the bracket at the caret and its matching bracket
+func (c *CodeEditDef) paintBrackets(gtx C) {
+	start, end := c.Selection()
+	if !c.Focused() || start != end {
+		return
+	}
+	p, m := -1, -1
+	if start > 0 {
+		p, m = c.matchBracket(start - 1)
+	}
+	if p < 0 {
+		p, m = c.matchBracket(start)
+	}
+	if p < 0 {
+		return
+	}
+	col := MulAlpha(c.th.Bg(Primary), 70)
+	if m < 0 {
+		col = MulAlpha(c.th.Bg(Error), 90)
+	}
+	for _, pos := range []int{p, m} {
+		if pos < 0 {
+			continue
+		}
+		c.regions = c.Regions(pos, pos+1, c.regions)
+		for _, r := range c.regions {
+			paint.FillShape(gtx.Ops, col, clip.Rect(r.Bounds).Op())
+		}
+	}
+}
+
+// matchBracket returns pos and the position of the matching bracket if the rune at pos is a bracket.
+// The match is -1 if there is no matching bracket, and pos is -1 if it is not a bracket.
+func (c *CodeEditDef) matchBracket(pos int) (int, int) {
+	const brackets = "()[]{}"
+	if pos >= len(c.src) || c.quoted(pos) {
+		return -1, -1
+	}
+	i := strings.IndexRune(brackets, c.src[pos])
+	if i < 0 {
+		return -1, -1
+	}
+	left, right := rune(brackets[i&^1]), rune(brackets[i|1])
+	dir := 1
+	if i&1 == 1 {
+		dir = -1
+	}
+	depth := 0
+	for j := pos; j >= 0 && j < len(c.src); j += dir {
+		if (c.src[j] != left && c.src[j] != right) || c.quoted(j) {
+			continue
+		}
+		if (c.src[j] == left) == (dir > 0) {
+			depth++
+		} else {
+			depth--
+		}
+		if depth == 0 {
+			return pos, j
+		}
+	}
+	return pos, -1
+}
+
+// quoted returns true if the rune at pos is within a string or comment token
+func (c *CodeEditDef) quoted(pos int) bool {
+	i := sort.Search(len(c.tokens), func(i int) bool { return c.tokens[i].End > pos })
+	if i == len(c.tokens) || c.tokens[i].Start > pos {
+		return false
+	}
+	return c.tokens[i].Kind == TokString || c.tokens[i].Kind == TokComment
+}
@@ -0,0 +1,498 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/widget"
+)
+
+// ColorPickerDef is a color selector with a saturation/lightness area, a hue bar,
+// an alpha slider, hex entry, the tones of the theme pallet and recently used colors.
+type ColorPickerDef struct {
+	Base
+	Clickable
+	value *color.NRGBA
+	// The hsl values are kept, so that the hue is not lost when the color is gray
+	h, s, l  float64
+	last     color.NRGBA
+	alpha    float32
+	slider   layout.Widget
+	edit     *EditDef
+	lastText string
+	typed    bool
+	// Positions in the panel from the last layout
+	area, hue, swatches, recent image.Rectangle
+	swatchSize                  int
+	areaTag                     struct{}
+	hueTag                      struct{}
+	swatchTag                   struct{}
+	recentTag                   struct{}
+	// The compact variant shows the color in a box that opens the panel as a popup
+	compact  bool
+	open     bool
+	catcher  struct{}
+	popupTag struct{}
+}
+
+// ColorOption is options specific to the color picker
+type ColorOption func(*ColorPickerDef)
+
+const maxRecentColors = 11
+
+// swatchTones is the tones shown for each color in the theme pallet
+var swatchTones = []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 95, 99}
+
+// recentColors is the colors last picked, newest first, guarded by GuiLock
+var recentColors []color.NRGBA
+
+// ColorPicker returns a panel for selecting the color in value. Use the option
+// Compact() to get a box that fits into table cells and opens the panel as a popup.
+func ColorPicker(th *Theme, value *color.NRGBA, options ...Option) layout.Widget {
+	c := &ColorPickerDef{value: value}
+	c.th = th
+	c.role = Canvas
+	c.Font = &th.DefaultFont
+	c.cornerRadius = th.BorderCornerRadius
+	c.padding = th.OutsidePadding
+	c.edit = new(EditDef)
+	c.edit.setDefaults(th)
+	c.edit.padding = layout.Inset{}
+	c.edit.Filter = "#0123456789abcdefABCDEF"
+	c.edit.hint = "#RRGGBB"
+	c.slider = Slider(th, &c.alpha, 0, 255)
+	for _, option := range options {
+		option.apply(c)
+	}
+	c.fromColor(*value)
+	return c.Layout
+}
+
+// Compact shows the color picker as a small box with the color and its hex code.
+// Clicking it opens the color picker as a popup.
+func Compact() ColorOption {
+	return func(c *ColorPickerDef) {
+		c.compact = true
+	}
+}
+
+func (o ColorOption) apply(cfg interface{}) {
+	if c, ok := cfg.(*ColorPickerDef); ok {
+		o(c)
+	}
+}
+
+// RecentColors returns the colors last picked by the user, newest first
+func RecentColors() []color.NRGBA {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return append([]color.NRGBA(nil), recentColors...)
+}
+
+func addRecentColor(c color.NRGBA) {
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	list := []color.NRGBA{c}
+	for _, r := range recentColors {
+		if r != c && len(list) < maxRecentColors {
+			list = append(list, r)
+		}
+	}
+	recentColors = list
+}
+
+func (c *ColorPickerDef) get() color.NRGBA {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return *c.value
+}
+
+// set stores a color picked by the user and calls the Do() handler
+func (c *ColorPickerDef) set(col color.NRGBA) {
+	GuiLock.Lock()
+	*c.value = col
+	GuiLock.Unlock()
+	c.sync()
+	if c.onUserChange != nil {
+		c.onUserChange()
+	}
+}
+
+// setHsl stores the color given by the hsl values, keeping the alpha
+func (c *ColorPickerDef) setHsl() {
+	col := Hsl2rgb(c.h, c.s, c.l)
+	col.A = c.get().A
+	GuiLock.Lock()
+	*c.value = col
+	GuiLock.Unlock()
+	c.last = col
+	if c.onUserChange != nil {
+		c.onUserChange()
+	}
+}
+
+// sync updates the hsl values and the alpha when the value has been changed
+func (c *ColorPickerDef) sync() {
+	if v := c.get(); v != c.last {
+		c.fromColor(v)
+	}
+}
+
+func (c *ColorPickerDef) fromColor(v color.NRGBA) {
+	h, s, l := Rgb2hsl(v)
+	if s > 0 {
+		c.h = h
+	}
+	c.s, c.l = s, l
+	c.alpha = float32(v.A)
+	c.last = v
+}
+
+func (c *ColorPickerDef) handleEvents(gtx C) {
+	for _, e := range gtx.Events(&c.areaTag) {
+		if e, ok := e.(pointer.Event); ok {
+			switch e.Type {
+			case pointer.Press, pointer.Drag:
+				p := e.Position
+				c.s = float64(Clamp((p.X-float32(c.area.Min.X))/float32(c.area.Dx()), 0, 1))
+				c.l = 1 - float64(Clamp((p.Y-float32(c.area.Min.Y))/float32(c.area.Dy()), 0, 1))
+				c.setHsl()
+			case pointer.Release:
+				addRecentColor(c.get())
+			}
+		}
+	}
+	for _, e := range gtx.Events(&c.hueTag) {
+		if e, ok := e.(pointer.Event); ok {
+			switch e.Type {
+			case pointer.Press, pointer.Drag:
+				x := Clamp((e.Position.X-float32(c.hue.Min.X))/float32(c.hue.Dx()), 0, 1)
+				c.h = float64(x) * 359.9
+				c.setHsl()
+			case pointer.Release:
+				addRecentColor(c.get())
+			}
+		}
+	}
+	for _, e := range gtx.Events(&c.swatchTag) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+			p := e.Position.Round().Sub(c.swatches.Min).Div(Max(1, c.swatchSize))
+			pallet := c.pallet()
+			if p.Y >= 0 && p.Y < len(pallet) && p.X >= 0 && p.X < len(swatchTones) {
+				col := Tone(pallet[p.Y], swatchTones[p.X])
+				c.set(col)
+				addRecentColor(col)
+			}
+		}
+	}
+	for _, e := range gtx.Events(&c.recentTag) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+			i := (e.Position.Round().X - c.recent.Min.X) / Max(1, c.swatchSize)
+			if recent := RecentColors(); i >= 0 && i < len(recent) {
+				c.set(recent[i])
+				addRecentColor(recent[i])
+			}
+		}
+	}
+}
+
+// pallet returns the key colors of the theme, one row of swatches for each
+func (c *ColorPickerDef) pallet() []color.NRGBA {
+	p := c.th.Pallet
+	return []color.NRGBA{p.PrimaryColor, p.SecondaryColor, p.TertiaryColor,
+		p.ErrorColor, p.NeutralColor, p.NeutralVariantColor}
+}
+
+// Layout draws the color picker panel, or the compact box
+func (c *ColorPickerDef) Layout(gtx C) D {
+	if c.compact {
+		return c.layoutCompact(gtx)
+	}
+	c.CheckDisable(gtx)
+	defer op.Offset(image.Pt(gtx.Dp(c.padding.Left), gtx.Dp(c.padding.Top))).Push(gtx.Ops).Pop()
+	dims := c.layoutPanel(gtx)
+	dims.Size = dims.Size.Add(image.Pt(
+		gtx.Dp(c.padding.Left+c.padding.Right),
+		gtx.Dp(c.padding.Top+c.padding.Bottom)))
+	return dims
+}
+
+// layoutPanel draws the parts of the color picker below each other
+func (c *ColorPickerDef) layoutPanel(gtx C) D {
+	th := c.th
+	c.handleEvents(gtx)
+	c.sync()
+	w := gtx.Sp(th.TextSize * 18)
+	if !c.compact && c.width > 0 {
+		w = gtx.Dp(c.width)
+	}
+	w = Min(w, gtx.Constraints.Max.X)
+	pad := gtx.Dp(th.InsidePadding.Left)
+	inner := w - 2*pad
+	y := pad
+
+	c.area = image.Rect(pad, y, pad+inner, y+inner*3/5)
+	c.paintArea(gtx)
+	y = c.area.Max.Y + pad
+
+	c.hue = image.Rect(pad, y, pad+inner, y+gtx.Sp(th.TextSize))
+	c.paintHue(gtx)
+	y = c.hue.Max.Y + pad
+
+	// Hex entry, with a preview of the color to the left
+	v := c.get()
+	if !c.edit.Focused() {
+		if s := Hex(v); s != c.edit.Text() {
+			c.edit.SetText(s)
+			c.edit.outlineColor = th.Fg(Outline)
+		}
+		c.lastText = c.edit.Text()
+		if c.typed {
+			c.typed = false
+			addRecentColor(v)
+		}
+	}
+	previewWidth := gtx.Sp(th.TextSize * 3)
+	o := op.Offset(image.Pt(pad+previewWidth+pad, y)).Push(gtx.Ops)
+	eg := gtx
+	eg.Constraints = layout.Exact(image.Pt(inner-previewWidth-pad, 0))
+	eg.Constraints.Max.Y = inf
+	dims := c.edit.Layout(eg)
+	o.Pop()
+	c.updateText()
+	preview := image.Rect(pad, y, pad+previewWidth, y+dims.Size.Y)
+	paintColor(gtx, preview, v, gtx.Dp(th.BorderCornerRadius))
+	y = preview.Max.Y + pad
+
+	o = op.Offset(image.Pt(pad, y)).Push(gtx.Ops)
+	sg := gtx
+	sg.Constraints = layout.Constraints{Min: image.Pt(inner, 0), Max: image.Pt(inner, inf)}
+	dims = c.slider(sg)
+	o.Pop()
+	if a := uint8(c.alpha + 0.5); a != v.A {
+		v.A = a
+		c.set(v)
+	}
+	y += dims.Size.Y + pad
+
+	// Tones of the theme pallet
+	pallet := c.pallet()
+	c.swatchSize = inner / len(swatchTones)
+	c.swatches = image.Rect(pad, y, pad+c.swatchSize*len(swatchTones), y+c.swatchSize*len(pallet))
+	for i, p := range pallet {
+		for j, tone := range swatchTones {
+			r := image.Rectangle{Max: image.Pt(c.swatchSize, c.swatchSize)}
+			r = r.Add(c.swatches.Min).Add(image.Pt(j*c.swatchSize, i*c.swatchSize))
+			paint.FillShape(gtx.Ops, Tone(p, tone), clip.Rect(r.Inset(1)).Op())
+		}
+	}
+	y = c.swatches.Max.Y + pad
+
+	if recent := RecentColors(); len(recent) > 0 {
+		c.recent = image.Rect(pad, y, pad+c.swatchSize*len(recent), y+c.swatchSize)
+		for i, col := range recent {
+			r := image.Rect(0, 0, c.swatchSize, c.swatchSize).Add(c.recent.Min).Add(image.Pt(i*c.swatchSize, 0))
+			paintColor(gtx, r.Inset(1), col, c.swatchSize/4)
+		}
+		y = c.recent.Max.Y + pad
+	} else {
+		c.recent = image.Rectangle{}
+	}
+
+	if gtx.Queue != nil {
+		for _, t := range []struct {
+			tag  *struct{}
+			rect image.Rectangle
+		}{{&c.areaTag, c.area}, {&c.hueTag, c.hue}, {&c.swatchTag, c.swatches}, {&c.recentTag, c.recent}} {
+			r := clip.Rect(t.rect).Push(gtx.Ops)
+			pointer.InputOp{Tag: t.tag, Types: pointer.Press | pointer.Drag | pointer.Release}.Add(gtx.Ops)
+			pointer.CursorPointer.Add(gtx.Ops)
+			r.Pop()
+		}
+	}
+	return D{Size: image.Pt(w, y)}
+}
+
+// updateText stores the color typed in the hex entry
+func (c *ColorPickerDef) updateText() {
+	s := c.edit.Text()
+	if !c.edit.Focused() || s == c.lastText {
+		return
+	}
+	c.lastText = s
+	c.edit.outlineColor = c.th.Fg(Outline)
+	if col, err := ParseHex(s); err == nil {
+		c.typed = true
+		c.set(col)
+	} else if len(strings.TrimPrefix(s, "#")) > 8 {
+		c.edit.outlineColor = c.th.Bg(Error)
+	}
+}
+
+// paintArea draws the saturation along the x axis and the lightness along the y axis,
+// with a ring at the current color
+func (c *ColorPickerDef) paintArea(gtx C) {
+	r := c.area
+	mid := float32(r.Min.Y+r.Max.Y) / 2
+	cl := clip.Rect(r).Push(gtx.Ops)
+	paint.LinearGradientOp{
+		Stop1: f32.Pt(float32(r.Min.X), 0), Color1: Hsl2rgb(c.h, 0, 0.5),
+		Stop2: f32.Pt(float32(r.Max.X), 0), Color2: Hsl2rgb(c.h, 1, 0.5),
+	}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	paint.LinearGradientOp{
+		Stop1: f32.Pt(0, float32(r.Min.Y)), Color1: White,
+		Stop2: f32.Pt(0, mid), Color2: WithAlpha(White, 0),
+	}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	paint.LinearGradientOp{
+		Stop1: f32.Pt(0, mid), Color1: WithAlpha(Black, 0),
+		Stop2: f32.Pt(0, float32(r.Max.Y)), Color2: Black,
+	}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	cl.Pop()
+	p := image.Pt(r.Min.X+int(c.s*float64(r.Dx())), r.Min.Y+int((1-c.l)*float64(r.Dy())))
+	paintMarker(gtx, image.Rectangle{Min: p, Max: p}.Inset(-gtx.Sp(c.th.TextSize*0.4)))
+}
+
+// paintHue draws the hues from red to red, with a marker at the current hue
+func (c *ColorPickerDef) paintHue(gtx C) {
+	r := c.hue
+	for k := 0; k < 6; k++ {
+		x0, x1 := r.Min.X+r.Dx()*k/6, r.Min.X+r.Dx()*(k+1)/6
+		cl := clip.Rect(image.Rect(x0, r.Min.Y, x1, r.Max.Y)).Push(gtx.Ops)
+		paint.LinearGradientOp{
+			Stop1: f32.Pt(float32(x0), 0), Color1: Hsl2rgb(float64(k*60), 1, 0.5),
+			Stop2: f32.Pt(float32(x1), 0), Color2: Hsl2rgb(float64((k+1)*60), 1, 0.5),
+		}.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+		cl.Pop()
+	}
+	x := r.Min.X + int(c.h/360*float64(r.Dx()))
+	d := gtx.Sp(c.th.TextSize * 0.2)
+	paintMarker(gtx, image.Rect(x-d, r.Min.Y-d/2, x+d, r.Max.Y+d/2))
+}
+
+// paintMarker draws a white ring with a dark outline, visible on any color
+func paintMarker(gtx C, r image.Rectangle) {
+	rr := Min(r.Dx(), r.Dy()) / 2
+	w := float32(gtx.Dp(2))
+	paint.FillShape(gtx.Ops, WithAlpha(Black, 160),
+		clip.Stroke{Path: clip.UniformRRect(r, rr).Path(gtx.Ops), Width: w * 2}.Op())
+	paint.FillShape(gtx.Ops, White,
+		clip.Stroke{Path: clip.UniformRRect(r, rr).Path(gtx.Ops), Width: w}.Op())
+}
+
+// paintColor fills r with col, over a checkerboard that shows the transparency
+func paintColor(gtx C, r image.Rectangle, col color.NRGBA, rr int) {
+	defer clip.UniformRRect(r, rr).Push(gtx.Ops).Pop()
+	if col.A < 255 {
+		paint.Fill(gtx.Ops, White)
+		d := Max(2, gtx.Dp(5))
+		for y := r.Min.Y; y < r.Max.Y; y += d {
+			for x := r.Min.X + (y-r.Min.Y)/d%2*d; x < r.Max.X; x += 2 * d {
+				paint.FillShape(gtx.Ops, RGB(0xCCCCCC), clip.Rect(image.Rect(x, y, x+d, y+d)).Op())
+			}
+		}
+	}
+	paint.Fill(gtx.Ops, col)
+}
+
+// layoutCompact draws a box with the color and its hex code, like a DropDown.
+// Clicking it opens the color picker panel as a popup.
+func (c *ColorPickerDef) layoutCompact(gtx C) D {
+	c.CheckDisable(gtx)
+	th := c.th
+	defer op.Offset(image.Pt(gtx.Dp(c.padding.Left), gtx.Dp(c.padding.Top))).Push(gtx.Ops).Pop()
+	if w := gtx.Dp(c.width); w > gtx.Constraints.Min.X && w < gtx.Constraints.Max.X {
+		gtx.Constraints.Min.X = w
+	} else if gtx.Constraints.Min.X == 0 {
+		gtx.Constraints.Min.X = Min(gtx.Sp(c.th.TextSize*9), gtx.Constraints.Max.X)
+	}
+	gtx.Constraints.Min.X = Max(0, gtx.Constraints.Min.X-gtx.Dp(c.padding.Left+c.padding.Right))
+	gtx.Constraints.Max.X = gtx.Constraints.Min.X
+
+	c.HandleEvents(gtx)
+	for c.Clicked() {
+		c.open = !c.open
+	}
+	for _, e := range gtx.Events(&c.catcher) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+			c.open = false
+		}
+	}
+	if gtx.Queue == nil {
+		c.open = false
+	}
+
+	v := c.get()
+	lineHeight := gtx.Sp(th.TextSize * 1.3)
+	top, left := gtx.Dp(th.InsidePadding.Top), gtx.Dp(th.InsidePadding.Left)
+	border := image.Rect(0, 0, gtx.Constraints.Max.X, lineHeight+top+gtx.Dp(th.InsidePadding.Bottom))
+	r := rr(gtx, c.cornerRadius, border.Dy())
+	if c.Focused() {
+		paintBorder(gtx, border, c.Fg(), th.BorderThickness*2, r)
+		paintFocusRing(gtx, th, border, r)
+	} else if c.Hovered() {
+		paintBorder(gtx, border, c.Fg(), th.BorderThickness*3/2, r)
+	} else {
+		paintBorder(gtx, border, c.Fg(), th.BorderThickness, r)
+	}
+	paintColor(gtx, image.Rect(left, top, left+lineHeight, top+lineHeight), v, gtx.Dp(th.BorderCornerRadius)/2)
+
+	// The hex code, leaving space for the icon
+	iconSize := border.Dy()
+	o := op.Offset(image.Pt(left+lineHeight+left, top)).Push(gtx.Ops)
+	lg := gtx
+	lg.Constraints.Min.X = 0
+	lg.Constraints.Max.X = Max(0, border.Dx()-2*left-lineHeight-iconSize)
+	paint.ColorOp{Color: c.Fg()}.Add(gtx.Ops)
+	_ = widget.Label{Alignment: text.Start, MaxLines: 1}.Layout(lg, th.Shaper, *c.Font, th.TextSize, Hex(v))
+	o.Pop()
+	o = op.Offset(image.Pt(border.Max.X-iconSize, 0)).Push(gtx.Ops)
+	ig := gtx
+	ig.Constraints = layout.Exact(image.Pt(iconSize, iconSize))
+	_ = icon.Layout(ig, c.Fg())
+	o.Pop()
+
+	if c.open {
+		macro := op.Record(gtx.Ops)
+		pg := gtx
+		pg.Constraints = layout.Constraints{Max: image.Pt(inf, inf)}
+		panel := c.layoutPanel(pg)
+		call := macro.Stop()
+		pos := image.Pt(0, border.Max.Y)
+		if WinY-CurrentY < panel.Size.Y+border.Dy() {
+			pos.Y = -panel.Size.Y
+		}
+		macro = op.Record(gtx.Ops)
+		// Clicks outside the popup close it
+		cl := clip.Rect(image.Rect(-inf, -inf, inf, inf)).Push(gtx.Ops)
+		pointer.InputOp{Tag: &c.catcher, Types: pointer.Press}.Add(gtx.Ops)
+		cl.Pop()
+		o := op.Offset(pos).Push(gtx.Ops)
+		cl = clip.Rect(image.Rectangle{Max: panel.Size}).Push(gtx.Ops)
+		pointer.InputOp{Tag: &c.popupTag, Types: pointer.Press}.Add(gtx.Ops)
+		cl.Pop()
+		drawPopup(gtx, th, panel.Size, call, &c.popupTag)
+		o.Pop()
+		op.Defer(gtx.Ops, macro.Stop())
+	}
+
+	pointer.CursorPointer.Add(gtx.Ops)
+	c.SetupEventHandlers(gtx, border.Max)
+	registerFocus(gtx, c, c.tabIndex)
+	return D{Size: image.Pt(
+		gtx.Constraints.Max.X+gtx.Dp(c.padding.Left+c.padding.Right),
+		border.Dy()+gtx.Dp(c.padding.Top+c.padding.Bottom))}
+}
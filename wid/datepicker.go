@@ -0,0 +1,492 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// DatePickerDef is a masked edit for dates with a popup calendar.
+type DatePickerDef struct {
+	picker
+	value       *time.Time
+	end         *time.Time
+	dateLayout  string
+	dateMask    string
+	weekNumbers bool
+	firstDay    time.Weekday
+	min, max    time.Time
+	disabled    func(day time.Time) bool
+	// Calendar state
+	month   time.Time
+	cursor  time.Time
+	anchor  time.Time
+	picking bool
+	hover   int
+	// Calendar geometry from the last layout
+	cell, pad, header, gridX, gridY, width int
+}
+
+// DateOption is options specific to date pickers
+type DateOption func(*DatePickerDef)
+
+var calendarIcon, prevIcon, nextIcon *Icon
+
+// DatePicker returns a masked edit for a date with a popup calendar. The time of day in value
+// is kept when a date is selected. Base options like W(), Lbl(), Hint() and TabIndex() are
+// used by the edit field. Dates are typed with the digits only, and the separators are added.
+func DatePicker(th *Theme, value *time.Time, options ...Option) layout.Widget {
+	d := &DatePickerDef{value: value, dateLayout: "2006-01-02", firstDay: time.Monday, hover: -1}
+	d.init(th, d, calendarIcon, d, options)
+	d.dateMask = d.dateLayout
+	if d.end != nil {
+		d.dateMask += " - " + d.dateLayout
+	}
+	d.edit.Filter = maskFilter(d.dateMask)
+	if d.edit.hint == "" {
+		d.edit.hint = strings.NewReplacer("2006", "YYYY", "01", "MM", "02", "DD").Replace(d.dateMask)
+	}
+	return d.Layout
+}
+
+func (o DateOption) apply(cfg interface{}) {
+	if d, ok := cfg.(*DatePickerDef); ok {
+		o(d)
+	}
+}
+
+// WeekNumbers shows the ISO week numbers to the left of the calendar
+func WeekNumbers() DateOption {
+	return func(d *DatePickerDef) {
+		d.weekNumbers = true
+	}
+}
+
+// FirstDay sets the first day of the week in the calendar. The default is Monday.
+func FirstDay(day time.Weekday) DateOption {
+	return func(d *DatePickerDef) {
+		d.firstDay = day
+	}
+}
+
+// MinDate sets the first date that can be selected
+func MinDate(t time.Time) DateOption {
+	return func(d *DatePickerDef) {
+		d.min = dateOf(t)
+	}
+}
+
+// MaxDate sets the last date that can be selected
+func MaxDate(t time.Time) DateOption {
+	return func(d *DatePickerDef) {
+		d.max = dateOf(t)
+	}
+}
+
+// DisabledDays sets a function that returns true for days that can not be selected,
+// like weekends or holidays.
+func DisabledDays(f func(day time.Time) bool) DateOption {
+	return func(d *DatePickerDef) {
+		d.disabled = f
+	}
+}
+
+// DateRange selects a range of dates. The first date is stored in the value given
+// to DatePicker, and the last date in end.
+func DateRange(end *time.Time) DateOption {
+	return func(d *DatePickerDef) {
+		d.end = end
+	}
+}
+
+// DateLayout sets the date format, like "02.01.2006". It must contain numbers only,
+// with separators between them. The default is "2006-01-02".
+func DateLayout(layout string) DateOption {
+	return func(d *DatePickerDef) {
+		d.dateLayout = layout
+	}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// dateOf returns the date of t at midnight
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// withDate returns old with the date changed to the date of day
+func withDate(old time.Time, day time.Time) time.Time {
+	y, m, d := day.Date()
+	if old.IsZero() {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+	return time.Date(y, m, d, old.Hour(), old.Minute(), old.Second(), old.Nanosecond(), old.Location())
+}
+
+// maskFilter returns the characters that can be typed in an edit with the given mask
+func maskFilter(mask string) string {
+	filter := "0123456789"
+	for _, r := range mask {
+		if !isDigit(r) && !strings.ContainsRune(filter, r) {
+			filter += string(r)
+		}
+	}
+	return filter
+}
+
+// applyMask places the digits in s at the digit positions of mask, and
+// adds the separators between them.
+func applyMask(mask, s string) string {
+	var digits []rune
+	for _, r := range s {
+		if isDigit(r) {
+			digits = append(digits, r)
+		}
+	}
+	var b strings.Builder
+	for _, m := range mask {
+		if len(digits) == 0 {
+			break
+		}
+		if isDigit(m) {
+			b.WriteRune(digits[0])
+			digits = digits[1:]
+		} else {
+			b.WriteRune(m)
+		}
+	}
+	return b.String()
+}
+
+// allowed returns true if day can be selected
+func (d *DatePickerDef) allowed(day time.Time) bool {
+	day = dateOf(day)
+	if !d.min.IsZero() && day.Before(d.min) || !d.max.IsZero() && day.After(d.max) {
+		return false
+	}
+	return d.disabled == nil || !d.disabled(day)
+}
+
+// dates returns the selected dates. The last date is zero when not in range mode.
+func (d *DatePickerDef) dates() (first, last time.Time) {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	first = *d.value
+	if d.end != nil {
+		last = *d.end
+	}
+	return first, last
+}
+
+// setDates stores the selected dates and calls the Do() handler
+func (d *DatePickerDef) setDates(first, last time.Time) {
+	GuiLock.Lock()
+	*d.value = withDate(*d.value, first)
+	if d.end != nil {
+		*d.end = withDate(*d.end, last)
+	}
+	GuiLock.Unlock()
+	d.changed()
+}
+
+// format returns the text shown in the edit field
+func (d *DatePickerDef) format() string {
+	first, last := d.dates()
+	if first.IsZero() {
+		return ""
+	}
+	s := first.Format(d.dateLayout)
+	if d.end != nil && !last.IsZero() {
+		s += " - " + last.Format(d.dateLayout)
+	}
+	return s
+}
+
+func (d *DatePickerDef) mask(s string) string {
+	return applyMask(d.dateMask, s)
+}
+
+var errDate = errors.New("date is not valid")
+
+// store saves the dates in a complete text
+func (d *DatePickerDef) store(s string) error {
+	if len(s) != len(d.dateMask) {
+		return errIncomplete
+	}
+	first, err := time.ParseInLocation(d.dateLayout, s[:len(d.dateLayout)], time.Local)
+	if err != nil || !d.allowed(first) {
+		return errDate
+	}
+	var last time.Time
+	if d.end != nil {
+		last, err = time.ParseInLocation(d.dateLayout, s[len(s)-len(d.dateLayout):], time.Local)
+		if err != nil || !d.allowed(last) || last.Before(first) {
+			return errDate
+		}
+	}
+	d.setDates(first, last)
+	return nil
+}
+
+// opened shows the month of the selected date, or the current month
+func (d *DatePickerDef) opened() {
+	first, _ := d.dates()
+	if first.IsZero() {
+		first = time.Now()
+	}
+	d.picking = false
+	d.hover = -1
+	d.setCursor(dateOf(first))
+}
+
+// setCursor moves the keyboard cursor, and shows its month
+func (d *DatePickerDef) setCursor(day time.Time) {
+	d.cursor = day
+	d.month = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+}
+
+// pick selects day. In range mode the first call selects the start of the range.
+func (d *DatePickerDef) pick(day time.Time) {
+	if !d.allowed(day) {
+		return
+	}
+	if d.end == nil {
+		d.setDates(day, time.Time{})
+		d.closePopup(true)
+		return
+	}
+	if !d.picking {
+		d.anchor = day
+		d.picking = true
+		return
+	}
+	first, last := d.anchor, day
+	if last.Before(first) {
+		first, last = last, first
+	}
+	d.setDates(first, last)
+	d.closePopup(true)
+}
+
+// firstShown is the first day in the calendar grid
+func (d *DatePickerDef) firstShown() time.Time {
+	n := (int(d.month.Weekday()) - int(d.firstDay) + 7) % 7
+	return d.month.AddDate(0, 0, -n)
+}
+
+// hit returns the index of the day at p, -2 and -3 for the previous and next
+// month buttons, or -1 for anything else.
+func (d *DatePickerDef) hit(p image.Point) int {
+	if p.Y >= d.pad && p.Y < d.pad+d.header {
+		if p.X < d.pad+d.cell {
+			return -2
+		} else if p.X >= d.width-d.pad-d.cell {
+			return -3
+		}
+	}
+	if p.X < d.gridX || p.X >= d.gridX+7*d.cell || p.Y < d.gridY || p.Y >= d.gridY+6*d.cell {
+		return -1
+	}
+	return (p.Y-d.gridY)/d.cell*7 + (p.X-d.gridX)/d.cell
+}
+
+func (d *DatePickerDef) popupPointer(e pointer.Event) {
+	i := d.hit(e.Position.Round())
+	switch e.Type {
+	case pointer.Move:
+		d.hover = i
+	case pointer.Leave:
+		d.hover = -1
+	case pointer.Press:
+		if i == -2 {
+			d.setCursor(d.month.AddDate(0, -1, 0))
+		} else if i == -3 {
+			d.setCursor(d.month.AddDate(0, 1, 0))
+		} else if i >= 0 {
+			d.pick(d.firstShown().AddDate(0, 0, i))
+		}
+	}
+}
+
+func (d *DatePickerDef) popupKeys() key.Set {
+	return "(Shift)-[⇞,⇟]|←|→|↑|↓|⇱|⇲|⏎|⌤|Space"
+}
+
+// popupKey moves the cursor in the calendar
+func (d *DatePickerDef) popupKey(e key.Event) {
+	c := d.cursor
+	switch e.Name {
+	case key.NameLeftArrow:
+		c = c.AddDate(0, 0, -1)
+	case key.NameRightArrow:
+		c = c.AddDate(0, 0, 1)
+	case key.NameUpArrow:
+		c = c.AddDate(0, 0, -7)
+	case key.NameDownArrow:
+		c = c.AddDate(0, 0, 7)
+	case key.NamePageUp:
+		if e.Modifiers.Contain(key.ModShift) {
+			c = c.AddDate(-1, 0, 0)
+		} else {
+			c = c.AddDate(0, -1, 0)
+		}
+	case key.NamePageDown:
+		if e.Modifiers.Contain(key.ModShift) {
+			c = c.AddDate(1, 0, 0)
+		} else {
+			c = c.AddDate(0, 1, 0)
+		}
+	case key.NameHome:
+		c = d.month
+	case key.NameEnd:
+		c = d.month.AddDate(0, 1, -1)
+	case key.NameReturn, key.NameEnter, key.NameSpace:
+		d.pick(c)
+		return
+	}
+	d.setCursor(c)
+}
+
+// centeredText draws txt in the middle of rect
+func centeredText(gtx C, th *Theme, rect image.Rectangle, txt string, col color.NRGBA, size unit.Sp) {
+	c := gtx
+	c.Constraints = layout.Constraints{Max: rect.Size()}
+	macro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: col}.Add(gtx.Ops)
+	dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, size, txt)
+	call := macro.Stop()
+	defer op.Offset(rect.Min.Add(rect.Size().Sub(dims.Size).Div(2))).Push(gtx.Ops).Pop()
+	call.Add(gtx.Ops)
+}
+
+// layoutPopup draws the month with a header, the names of the days and 6 weeks
+func (d *DatePickerDef) layoutPopup(gtx C) D {
+	th := d.th
+	cols := 7
+	if d.weekNumbers {
+		cols = 8
+	}
+	d.cell = gtx.Sp(th.TextSize * 2.2)
+	d.pad = gtx.Dp(th.InsidePadding.Left)
+	d.header = d.cell
+	d.gridX = d.pad + (cols-7)*d.cell
+	d.gridY = d.pad + d.header + d.cell*3/4
+	d.width = 2*d.pad + cols*d.cell
+	size := image.Pt(d.width, d.gridY+6*d.cell+d.pad)
+	fg := th.Fg(Canvas)
+	small := th.TextSize * 0.85
+
+	// Header with the month and buttons for the previous and next month
+	title := image.Rect(d.pad, d.pad, d.width-d.pad, d.pad+d.header)
+	centeredText(gtx, th, title, d.month.Format("January 2006"), fg, th.TextSize)
+	ic := gtx
+	ic.Constraints = layout.Exact(image.Pt(d.cell/2, d.cell/2))
+	for i, icon := range []*Icon{prevIcon, nextIcon} {
+		x := d.pad
+		if i == 1 {
+			x = d.width - d.pad - d.cell
+		}
+		btn := image.Rect(x, d.pad, x+d.cell, d.pad+d.header)
+		if d.hover == -2-i {
+			paint.FillShape(gtx.Ops, MulAlpha(fg, 24), clip.Ellipse(btn).Op(gtx.Ops))
+		}
+		o := op.Offset(btn.Min.Add(image.Pt(d.cell/4, d.cell/4))).Push(gtx.Ops)
+		_ = icon.Layout(ic, fg)
+		o.Pop()
+	}
+
+	// Names of the days
+	y := d.pad + d.header
+	for i := 0; i < 7; i++ {
+		wd := time.Weekday((int(d.firstDay) + i) % 7)
+		rect := image.Rect(d.gridX+i*d.cell, y, d.gridX+(i+1)*d.cell, d.gridY)
+		centeredText(gtx, th, rect, wd.String()[:2], MulAlpha(fg, 160), small)
+	}
+
+	// The selected dates, or the range being selected
+	first, last := d.dates()
+	first, last = dateOf(first), dateOf(last)
+	start := d.firstShown()
+	if d.picking {
+		first, last = d.anchor, d.anchor
+		if d.hover >= 0 {
+			last = start.AddDate(0, 0, d.hover)
+		}
+		if last.Before(first) {
+			first, last = last, first
+		}
+	} else if d.end == nil {
+		last = first
+	}
+	today := dateOf(time.Now())
+	for i := 0; i < 42; i++ {
+		day := start.AddDate(0, 0, i)
+		rect := image.Rect(d.gridX+i%7*d.cell, d.gridY+i/7*d.cell, d.gridX+(i%7+1)*d.cell, d.gridY+(i/7+1)*d.cell)
+		if d.weekNumbers && i%7 == 0 {
+			// The ISO week is the week of the Thursday
+			thursday := day.AddDate(0, 0, (int(time.Thursday)-int(d.firstDay)+7)%7)
+			_, week := thursday.ISOWeek()
+			wr := image.Rect(d.pad, rect.Min.Y, d.gridX, rect.Max.Y)
+			centeredText(gtx, th, wr, strconv.Itoa(week), MulAlpha(fg, 120), small)
+		}
+		col := fg
+		if day.Month() != d.month.Month() {
+			col = MulAlpha(fg, 110)
+		}
+		selected := !first.IsZero() && (day.Equal(first) || day.Equal(last))
+		inRange := !first.IsZero() && day.After(first) && day.Before(last)
+		circle := rect.Inset(d.cell / 10)
+		if inRange || selected && !first.Equal(last) {
+			// The band between the first and last date
+			band := image.Rect(rect.Min.X, circle.Min.Y, rect.Max.X, circle.Max.Y)
+			if day.Equal(first) {
+				band.Min.X = circle.Min.X + circle.Dx()/2
+			} else if day.Equal(last) {
+				band.Max.X = circle.Min.X + circle.Dx()/2
+			}
+			paint.FillShape(gtx.Ops, th.Bg(PrimaryContainer), clip.Rect(band).Op())
+			col = th.Fg(PrimaryContainer)
+		}
+		if selected {
+			paint.FillShape(gtx.Ops, th.Bg(Primary), clip.Ellipse(circle).Op(gtx.Ops))
+			col = th.Fg(Primary)
+		} else if i == d.hover && d.allowed(day) {
+			paint.FillShape(gtx.Ops, MulAlpha(fg, 24), clip.Ellipse(circle).Op(gtx.Ops))
+		}
+		if day.Equal(d.cursor) {
+			paintBorder(gtx, circle, th.Bg(Primary), th.BorderThickness*2, circle.Dx()/2)
+		} else if day.Equal(today) {
+			paintBorder(gtx, circle, MulAlpha(fg, 160), th.BorderThickness, circle.Dx()/2)
+		}
+		if !d.allowed(day) {
+			col = Disabled(col)
+		}
+		centeredText(gtx, th, rect, strconv.Itoa(day.Day()), col, th.TextSize)
+	}
+	return D{Size: size}
+}
+
+func init() {
+	calendarIcon, _ = NewIcon(icons.ActionEvent)
+	prevIcon, _ = NewIcon(icons.NavigationChevronLeft)
+	nextIcon, _ = NewIcon(icons.NavigationChevronRight)
+}
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"context"
+	"image"
+	"image/color"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// DialogDef is a modal dialog drawn on top of the form by RunWithContext.
+// The form is dimmed and disabled while a dialog is open.
+type DialogDef struct {
+	Base
+	title    layout.Widget
+	content  layout.Widget
+	buttons  []layout.Widget
+	onResult func(res int)
+	scrimTag struct{}
+	keyTag   struct{}
+	opened   bool
+	closed   bool
+}
+
+// dialogs is the stack of open dialogs, guarded by GuiLock. Only the last one is active.
+var dialogs []*DialogDef
+
+// OpenDialog shows a modal dialog with a title, any content and a row of buttons.
+// It returns at once, and onResult is called with the index of the button clicked,
+// or -1 if the dialog was closed by Escape. Enter selects the last button.
+func OpenDialog(th *Theme, title string, content layout.Widget, onResult func(res int), buttons ...string) *DialogDef {
+	d := &DialogDef{content: content, onResult: onResult}
+	d.th = th
+	d.role = Surface
+	d.padding = th.ButtonLabelPadding
+	d.cornerRadius = th.BorderCornerRadius * 3
+	if title != "" {
+		d.title = Label(th, title, Large(), Bold())
+	}
+	for i, b := range buttons {
+		i := i
+		d.buttons = append(d.buttons, TextButton(th, b, Do(func() { d.Close(i) })))
+	}
+	GuiLock.Lock()
+	dialogs = append(dialogs, d)
+	GuiLock.Unlock()
+	Invalidate()
+	return d
+}
+
+// ShowDialog shows a modal dialog and blocks until the user answers.
+// It returns the index of the button clicked, or -1 if the dialog was closed by
+// Escape or the context was cancelled. It must be called from a goroutine, not from
+// an event handler in the frame loop. Use OpenDialog there.
+func ShowDialog(ctx context.Context, th *Theme, title string, content layout.Widget, buttons ...string) int {
+	result := make(chan int, 1)
+	d := OpenDialog(th, title, content, func(res int) { result <- res }, buttons...)
+	select {
+	case res := <-result:
+		return res
+	case <-ctx.Done():
+		d.Close(-1)
+		return -1
+	}
+}
+
+// Confirm shows a message with Cancel and OK buttons, and returns true if OK was clicked.
+func Confirm(ctx context.Context, th *Theme, title string, msg string) bool {
+	return ShowDialog(ctx, th, title, Label(th, msg), "Cancel", "OK") == 1
+}
+
+// Alert shows a message with an OK button, and waits until it is closed.
+func Alert(ctx context.Context, th *Theme, title string, msg string) {
+	_ = ShowDialog(ctx, th, title, Label(th, msg), "OK")
+}
+
+// Close removes the dialog and reports res as its result. Only the first call has any effect.
+func (d *DialogDef) Close(res int) {
+	GuiLock.Lock()
+	if d.closed {
+		GuiLock.Unlock()
+		return
+	}
+	d.closed = true
+	for i := range dialogs {
+		if dialogs[i] == d {
+			dialogs = append(dialogs[:i], dialogs[i+1:]...)
+			break
+		}
+	}
+	GuiLock.Unlock()
+	if d.onResult != nil {
+		d.onResult(res)
+	}
+	Invalidate()
+}
+
+func modalOpen() bool {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return len(dialogs) > 0
+}
+
+// layoutDialogs draws all open dialogs. Only the topmost one receives events.
+func layoutDialogs(gtx C) {
+	GuiLock.RLock()
+	list := append([]*DialogDef(nil), dialogs...)
+	GuiLock.RUnlock()
+	for i, d := range list {
+		c := gtx
+		if i < len(list)-1 {
+			c.Queue = nil
+		}
+		_ = d.Layout(c)
+	}
+}
+
+// Layout dims the window and draws the dialog in the middle of it
+func (d *DialogDef) Layout(gtx C) D {
+	for _, e := range gtx.Events(&d.keyTag) {
+		if e, ok := e.(key.Event); ok && e.State == key.Press {
+			if e.Name == key.NameEscape {
+				d.Close(-1)
+			} else if e.Name == key.NameReturn && len(d.buttons) > 0 {
+				d.Close(len(d.buttons) - 1)
+			}
+		}
+	}
+	win := image.Rectangle{Max: gtx.Constraints.Max}
+	paint.FillShape(gtx.Ops, color.NRGBA{A: 0x80}, clip.Rect(win).Op())
+	// The scrim takes all pointer events, so that the form below is not reachable
+	defer clip.Rect(win).Push(gtx.Ops).Pop()
+	pointer.InputOp{
+		Tag:          &d.scrimTag,
+		Types:        pointer.Press | pointer.Release | pointer.Move | pointer.Drag | pointer.Scroll,
+		ScrollBounds: image.Rect(-inf, -inf, inf, inf),
+	}.Add(gtx.Ops)
+	if gtx.Queue != nil {
+		key.InputOp{Tag: &d.keyTag, Keys: "⎋|⏎"}.Add(gtx.Ops)
+		if !d.opened {
+			// Take the focus from the form
+			key.FocusOp{Tag: &d.keyTag}.Add(gtx.Ops)
+			d.opened = true
+		}
+	}
+
+	c := gtx
+	width := Min(win.Dx()*9/10, gtx.Sp(d.th.TextSize*36))
+	c.Constraints = layout.Constraints{Min: image.Pt(width, 0), Max: image.Pt(width, win.Dy()*9/10)}
+	macro := op.Record(gtx.Ops)
+	dims := d.padding.Layout(c, d.layout)
+	call := macro.Stop()
+
+	pos := win.Max.Sub(dims.Size).Div(2)
+	defer op.Offset(pos).Push(gtx.Ops).Pop()
+	rect := image.Rectangle{Max: dims.Size}
+	rr := Min(gtx.Dp(d.cornerRadius), dims.Size.Y/2)
+	DrawShadow(gtx, rect, rr, gtx.Dp(d.th.Elevation))
+	paint.FillShape(gtx.Ops, d.Bg(), clip.UniformRRect(rect, rr).Op(gtx.Ops))
+	call.Add(gtx.Ops)
+	return dims
+}
+
+// layout stacks the title, the content and the buttons vertically
+func (d *DialogDef) layout(gtx C) D {
+	c := gtx
+	c.Constraints.Min.Y = 0
+	var title, buttons D
+	var titleCall, buttonsCall op.CallOp
+	if d.title != nil {
+		macro := op.Record(gtx.Ops)
+		title = d.title(c)
+		titleCall = macro.Stop()
+	}
+	if len(d.buttons) > 0 {
+		macro := op.Record(gtx.Ops)
+		children := make([]layout.FlexChild, len(d.buttons))
+		for i := range d.buttons {
+			children[i] = layout.Rigid(d.buttons[i])
+		}
+		buttons = layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceStart}.Layout(c, children...)
+		buttonsCall = macro.Stop()
+	}
+	titleCall.Add(gtx.Ops)
+	y := title.Size.Y
+	if d.content != nil {
+		c.Constraints.Min.X = 0
+		c.Constraints.Max.Y = Max(0, c.Constraints.Max.Y-title.Size.Y-buttons.Size.Y)
+		o := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
+		y += d.content(c).Size.Y
+		o.Pop()
+	}
+	o := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
+	buttonsCall.Add(gtx.Ops)
+	o.Pop()
+	return D{Size: image.Pt(gtx.Constraints.Max.X, y+buttons.Size.Y)}
+}
@@ -0,0 +1,541 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// Panel is a widget that can be docked. Panels are moved by dragging their title.
+type Panel struct {
+	// ID identifies the panel in saved layouts. The title is used when it is empty.
+	ID    string
+	Title string
+	W     layout.Widget
+	tag   struct{}
+	// ref is set by the DockRef option
+	ref **DockDef
+}
+
+// DockDef lays out panels in splits and tabs. Panels are dragged by their title to the
+// edge of another panel to split it, or to its middle to add a tab. The layout is saved and
+// restored with json.Marshal and json.Unmarshal.
+type DockDef struct {
+	th     *Theme
+	panels map[string]*Panel
+	order  []*Panel
+	root   *dockNode
+	// pressed is the panel whose title was pressed, and source the node it is in
+	pressed *Panel
+	source  *dockNode
+	start   f32.Point
+	pos     f32.Point
+	drag    bool
+}
+
+// dockNode is a node in the layout tree. It is either a split with children, or tabs with panels.
+type dockNode struct {
+	Axis     string      `json:"axis,omitempty"`
+	Ratios   []float32   `json:"ratios,omitempty"`
+	Children []*dockNode `json:"children,omitempty"`
+	Tabs     []string    `json:"tabs,omitempty"`
+	Active   int         `json:"active,omitempty"`
+	split    *SplitDef
+	rect     image.Rectangle
+}
+
+type dockZone int
+
+const (
+	zoneCenter dockZone = iota
+	zoneLeft
+	zoneRight
+	zoneTop
+	zoneBottom
+)
+
+const (
+	axisHorizontal = "horizontal"
+	axisVertical   = "vertical"
+)
+
+var errDockLayout = errors.New("invalid dock layout")
+
+// DockRef is an option for Dock that stores the dock in d, so that the layout can be saved
+// and restored with json.Marshal and json.Unmarshal. It is given in the list of panels,
+// but does not add a panel.
+func DockRef(d **DockDef) *Panel {
+	return &Panel{ref: d}
+}
+
+// Dock returns a layout manager with the panels side by side.
+func Dock(th *Theme, panels ...*Panel) layout.Widget {
+	d := &DockDef{th: th, panels: make(map[string]*Panel)}
+	root := &dockNode{Axis: axisHorizontal}
+	for _, p := range panels {
+		if p.ref != nil {
+			*p.ref = d
+			continue
+		}
+		if p.ID == "" {
+			p.ID = p.Title
+		}
+		d.panels[p.ID] = p
+		d.order = append(d.order, p)
+		root.Children = append(root.Children, &dockNode{Tabs: []string{p.ID}})
+	}
+	d.root = root
+	if len(root.Children) == 1 {
+		d.root = root.Children[0]
+	}
+	return d.Layout
+}
+
+// MarshalJSON returns the layout of the panels, with the panels given by their ID
+func (d *DockDef) MarshalJSON() ([]byte, error) {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return json.Marshal(d.root)
+}
+
+// UnmarshalJSON restores a layout saved by MarshalJSON. Unknown panels are ignored,
+// and panels missing in the layout are added as tabs to the first panel.
+func (d *DockDef) UnmarshalJSON(data []byte) error {
+	var root dockNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	r, err := d.clean(&root, seen)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		r = &dockNode{}
+	}
+	first := r
+	for len(first.Children) > 0 {
+		first = first.Children[0]
+	}
+	for _, p := range d.order {
+		if !seen[p.ID] {
+			first.Tabs = append(first.Tabs, p.ID)
+		}
+	}
+	GuiLock.Lock()
+	d.root = r
+	d.pressed, d.drag = nil, false
+	GuiLock.Unlock()
+	Invalidate()
+	return nil
+}
+
+// clean validates a loaded node, and removes unknown and repeated panels and empty nodes
+func (d *DockDef) clean(n *dockNode, seen map[string]bool) (*dockNode, error) {
+	if len(n.Children) > 0 && len(n.Tabs) > 0 {
+		return nil, errDockLayout
+	}
+	if len(n.Children) == 0 {
+		var tabs []string
+		for _, id := range n.Tabs {
+			if d.panels[id] != nil && !seen[id] {
+				seen[id] = true
+				tabs = append(tabs, id)
+			}
+		}
+		if len(tabs) == 0 {
+			return nil, nil
+		}
+		n.Tabs = tabs
+		n.Active = Clamp(n.Active, 0, len(tabs)-1)
+		return n, nil
+	}
+	if n.Axis != axisHorizontal && n.Axis != axisVertical {
+		return nil, errDockLayout
+	}
+	var children []*dockNode
+	var ratios []float32
+	for i, c := range n.Children {
+		c, err := d.clean(c, seen)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			children = append(children, c)
+			if i < len(n.Ratios) {
+				ratios = append(ratios, n.Ratios[i])
+			}
+		}
+	}
+	switch len(children) {
+	case 0:
+		return nil, nil
+	case 1:
+		return children[0], nil
+	}
+	n.Children = children
+	n.Ratios = ratios
+	return n, nil
+}
+
+func (n *dockNode) axis() layout.Axis {
+	if n.Axis == axisVertical {
+		return layout.Vertical
+	}
+	return layout.Horizontal
+}
+
+// ratios returns the ratios of the children, resetting them if the count is wrong
+func (n *dockNode) ratios() []float32 {
+	if len(n.Ratios) != len(n.Children) {
+		n.Ratios = make([]float32, len(n.Children))
+		for i := range n.Ratios {
+			n.Ratios[i] = 1 / float32(len(n.Children))
+		}
+	}
+	return n.Ratios
+}
+
+// parentOf returns the parent of n and the index of n in it, or nil for the root
+func (d *DockDef) parentOf(n *dockNode) (*dockNode, int) {
+	var find func(p *dockNode) (*dockNode, int)
+	find = func(p *dockNode) (*dockNode, int) {
+		for i, c := range p.Children {
+			if c == n {
+				return p, i
+			}
+			if q, j := find(c); q != nil {
+				return q, j
+			}
+		}
+		return nil, 0
+	}
+	return find(d.root)
+}
+
+// replace puts n in the place of old in the tree
+func (d *DockDef) replace(old, n *dockNode) {
+	if p, i := d.parentOf(old); p != nil {
+		p.Children[i] = n
+		p.split = nil
+	} else {
+		d.root = n
+	}
+}
+
+// remove takes the panel out of the tabs in leaf, and removes the leaf when it gets empty
+func (d *DockDef) remove(id string, leaf *dockNode) {
+	for i, t := range leaf.Tabs {
+		if t == id {
+			leaf.Tabs = append(leaf.Tabs[:i], leaf.Tabs[i+1:]...)
+			break
+		}
+	}
+	leaf.Active = Clamp(leaf.Active, 0, Max(0, len(leaf.Tabs)-1))
+	if len(leaf.Tabs) > 0 {
+		return
+	}
+	p, i := d.parentOf(leaf)
+	if p == nil {
+		return
+	}
+	ratios := p.ratios()
+	p.Children = append(p.Children[:i], p.Children[i+1:]...)
+	p.Ratios = append(ratios[:i], ratios[i+1:]...)
+	normalize(p.Ratios)
+	p.split = nil
+	if len(p.Children) == 1 {
+		d.replace(p, p.Children[0])
+	}
+}
+
+// dock moves the panel from the source leaf to the zone of the target leaf
+func (d *DockDef) dock(p *Panel, source, target *dockNode, zone dockZone) {
+	if target == source && (zone == zoneCenter || len(source.Tabs) == 1) {
+		return
+	}
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	d.remove(p.ID, source)
+	if zone == zoneCenter {
+		target.Tabs = append(target.Tabs, p.ID)
+		target.Active = len(target.Tabs) - 1
+		return
+	}
+	leaf := &dockNode{Tabs: []string{p.ID}}
+	axis := axisHorizontal
+	if zone == zoneTop || zone == zoneBottom {
+		axis = axisVertical
+	}
+	after := 0
+	if zone == zoneRight || zone == zoneBottom {
+		after = 1
+	}
+	if parent, i := d.parentOf(target); parent != nil && parent.Axis == axis {
+		// Insert the leaf next to the target, taking half of its space
+		ratios := parent.ratios()
+		r := ratios[i] / 2
+		ratios[i] = r
+		i += after
+		parent.Children = append(parent.Children[:i], append([]*dockNode{leaf}, parent.Children[i:]...)...)
+		parent.Ratios = append(ratios[:i], append([]float32{r}, ratios[i:]...)...)
+		parent.split = nil
+		return
+	}
+	n := &dockNode{Axis: axis, Ratios: []float32{0.5, 0.5}, Children: []*dockNode{leaf, target}}
+	if after == 1 {
+		n.Children[0], n.Children[1] = target, leaf
+	}
+	d.replace(target, n)
+}
+
+// Layout draws the panels, and the drop zone while a panel is dragged
+func (d *DockDef) Layout(gtx C) D {
+	size := gtx.Constraints.Max
+	// The titles pass their pointer events on to the dock, giving positions in dock coordinates
+	r := clip.Rect{Max: size}.Push(gtx.Ops)
+	pointer.InputOp{Tag: d, Types: pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel}.Add(gtx.Ops)
+	r.Pop()
+	c := gtx
+	c.Constraints = layout.Exact(size)
+	root := d.root
+	d.layoutNode(c, root)
+	d.place(gtx, root, image.Rectangle{Max: size})
+	d.handleDrag(gtx)
+	if d.drag && d.pressed != nil {
+		if target := d.leafAt(d.root, d.pos.Round()); target != nil {
+			zone := d.zone(gtx, target, d.pos.Round())
+			d.paintZone(gtx, zoneRect(target.rect, zone))
+		}
+		d.paintGhost(gtx)
+	}
+	return D{Size: size}
+}
+
+func (d *DockDef) handleDrag(gtx C) {
+	for _, e := range gtx.Events(d) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case pointer.Press:
+			d.start, d.pos = e.Position, e.Position
+			d.drag = false
+		case pointer.Drag:
+			d.pos = e.Position
+			if dist := d.pos.Sub(d.start); d.pressed != nil && dist.X*dist.X+dist.Y*dist.Y > float32(gtx.Dp(8)*gtx.Dp(8)) {
+				d.drag = true
+			}
+		case pointer.Release:
+			if d.drag && d.pressed != nil {
+				if target := d.leafAt(d.root, e.Position.Round()); target != nil {
+					d.dock(d.pressed, d.source, target, d.zone(gtx, target, e.Position.Round()))
+				}
+			}
+			d.pressed, d.drag = nil, false
+		case pointer.Cancel:
+			d.pressed, d.drag = nil, false
+		}
+	}
+}
+
+func (d *DockDef) layoutNode(gtx C, n *dockNode) {
+	if len(n.Children) == 0 {
+		d.layoutTabs(gtx, n)
+		return
+	}
+	if n.split == nil {
+		panes := make([]Pane, len(n.Children))
+		ratios := n.ratios()
+		for i, c := range n.Children {
+			c := c
+			panes[i] = Pane{
+				W: func(gtx C) D {
+					d.layoutNode(gtx, c)
+					return D{Size: gtx.Constraints.Max}
+				},
+				Min:   unit.Dp(d.th.TextSize * 3),
+				Ratio: ratios[i],
+			}
+		}
+		n.split = newSplit(d.th, n.axis(), panes...)
+	}
+	split := n.split
+	split.Layout(gtx)
+	ratios := split.Ratios()
+	GuiLock.Lock()
+	n.Ratios = ratios
+	GuiLock.Unlock()
+}
+
+// titleHeight is the height of the tab bar above each panel
+func (d *DockDef) titleHeight(gtx C) int {
+	return gtx.Sp(d.th.TextSize * 2)
+}
+
+// layoutTabs draws the titles of the panels in a tab bar, and the active panel below
+func (d *DockDef) layoutTabs(gtx C, n *dockNode) {
+	th := d.th
+	size := gtx.Constraints.Max
+	barHeight := d.titleHeight(gtx)
+	pad := gtx.Dp(th.InsidePadding.Left) * 2
+	paint.FillShape(gtx.Ops, th.Bg(SurfaceVariant), clip.Rect{Max: image.Pt(size.X, barHeight)}.Op())
+	x := 0
+	for i, id := range n.Tabs {
+		p := d.panels[id]
+		for _, e := range gtx.Events(&p.tag) {
+			if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+				GuiLock.Lock()
+				n.Active = i
+				GuiLock.Unlock()
+				d.pressed, d.source = p, n
+			}
+		}
+		macro := op.Record(gtx.Ops)
+		lg := gtx
+		lg.Constraints = layout.Constraints{Max: image.Pt(Max(0, size.X-x-2*pad), barHeight)}
+		col := th.Fg(SurfaceVariant)
+		if i == n.Active {
+			col = th.Fg(Canvas)
+		}
+		paint.ColorOp{Color: ColDisabled(col, gtx.Queue == nil)}.Add(gtx.Ops)
+		dims := widget.Label{MaxLines: 1}.Layout(lg, th.Shaper, th.DefaultFont, th.TextSize, p.Title)
+		call := macro.Stop()
+		tab := image.Rect(x, 0, x+dims.Size.X+2*pad, barHeight)
+		if i == n.Active {
+			paint.FillShape(gtx.Ops, th.Bg(Canvas), clip.Rect(tab).Op())
+			line := gtx.Dp(2)
+			paint.FillShape(gtx.Ops, th.Bg(Primary), clip.Rect(image.Rect(tab.Min.X, tab.Max.Y-line, tab.Max.X, tab.Max.Y)).Op())
+		}
+		o := op.Offset(image.Pt(x+pad, (barHeight-dims.Size.Y)/2)).Push(gtx.Ops)
+		call.Add(gtx.Ops)
+		o.Pop()
+		cl := clip.Rect(tab).Push(gtx.Ops)
+		pass := pointer.PassOp{}.Push(gtx.Ops)
+		pointer.InputOp{Tag: &p.tag, Types: pointer.Press}.Add(gtx.Ops)
+		pointer.CursorGrab.Add(gtx.Ops)
+		pass.Pop()
+		cl.Pop()
+		x = tab.Max.X
+	}
+	if len(n.Tabs) == 0 {
+		return
+	}
+	p := d.panels[n.Tabs[Clamp(n.Active, 0, len(n.Tabs)-1)]]
+	o := op.Offset(image.Pt(0, barHeight)).Push(gtx.Ops)
+	c := gtx
+	c.Constraints = layout.Exact(image.Pt(size.X, Max(0, size.Y-barHeight)))
+	cl := clip.Rect{Max: c.Constraints.Max}.Push(gtx.Ops)
+	p.W(c)
+	cl.Pop()
+	o.Pop()
+}
+
+// place stores the rectangle of each node in dock coordinates, using the sizes of the splits
+func (d *DockDef) place(gtx C, n *dockNode, r image.Rectangle) {
+	n.rect = r
+	if len(n.Children) == 0 || n.split == nil || len(n.split.sizes) != len(n.Children) {
+		return
+	}
+	axis := n.axis()
+	sash := gtx.Dp(d.th.SashWidth)
+	pos := axis.Convert(r.Min).X
+	cross0, cross1 := axis.Convert(r.Min).Y, axis.Convert(r.Max).Y
+	for i, c := range n.Children {
+		size := n.split.sizes[i]
+		d.place(gtx, c, image.Rectangle{
+			Min: axis.Convert(image.Pt(pos, cross0)),
+			Max: axis.Convert(image.Pt(pos+size, cross1)),
+		})
+		pos += size + sash
+	}
+}
+
+// leafAt returns the leaf below p
+func (d *DockDef) leafAt(n *dockNode, p image.Point) *dockNode {
+	if !p.In(n.rect) {
+		return nil
+	}
+	if len(n.Children) == 0 {
+		return n
+	}
+	for _, c := range n.Children {
+		if l := d.leafAt(c, p); l != nil {
+			return l
+		}
+	}
+	return nil
+}
+
+// zone returns the drop zone at p in the leaf n. The title bar and the middle of
+// the panel add a tab, and the outer quarters split the panel.
+func (d *DockDef) zone(gtx C, n *dockNode, p image.Point) dockZone {
+	r := n.rect
+	if p.Y < r.Min.Y+d.titleHeight(gtx) || r.Dx() == 0 || r.Dy() == 0 {
+		return zoneCenter
+	}
+	x := float32(p.X-r.Min.X) / float32(r.Dx())
+	y := float32(p.Y-r.Min.Y) / float32(r.Dy())
+	zone, min := zoneCenter, float32(0.25)
+	for _, z := range []struct {
+		zone dockZone
+		dist float32
+	}{{zoneLeft, x}, {zoneRight, 1 - x}, {zoneTop, y}, {zoneBottom, 1 - y}} {
+		if z.dist < min {
+			zone, min = z.zone, z.dist
+		}
+	}
+	return zone
+}
+
+// zoneRect returns the part of r covered by the panel when it is dropped in zone
+func zoneRect(r image.Rectangle, zone dockZone) image.Rectangle {
+	c := r.Min.Add(r.Max).Div(2)
+	switch zone {
+	case zoneLeft:
+		r.Max.X = c.X
+	case zoneRight:
+		r.Min.X = c.X
+	case zoneTop:
+		r.Max.Y = c.Y
+	case zoneBottom:
+		r.Min.Y = c.Y
+	}
+	return r
+}
+
+func (d *DockDef) paintZone(gtx C, r image.Rectangle) {
+	col := d.th.Bg(Primary)
+	paint.FillShape(gtx.Ops, MulAlpha(col, 60), clip.Rect(r).Op())
+	paintBorder(gtx, r, col, 2, 0)
+}
+
+// paintGhost draws the title of the dragged panel at the pointer
+func (d *DockDef) paintGhost(gtx C) {
+	th := d.th
+	macro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: th.Fg(PrimaryContainer)}.Add(gtx.Ops)
+	lg := gtx
+	lg.Constraints = layout.Constraints{Max: image.Pt(inf, inf)}
+	dims := widget.Label{MaxLines: 1}.Layout(lg, th.Shaper, th.DefaultFont, th.TextSize, d.pressed.Title)
+	call := macro.Stop()
+	pad := gtx.Dp(th.InsidePadding.Left)
+	r := image.Rectangle{Max: dims.Size.Add(image.Pt(2*pad, pad))}
+	defer op.Offset(d.pos.Round().Add(image.Pt(pad, pad))).Push(gtx.Ops).Pop()
+	DrawShadow(gtx, r, gtx.Dp(th.BorderCornerRadius), 4)
+	paint.FillShape(gtx.Ops, th.Bg(PrimaryContainer), clip.UniformRRect(r, gtx.Dp(th.BorderCornerRadius)).Op(gtx.Ops))
+	op.Offset(image.Pt(pad, pad/2)).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+}
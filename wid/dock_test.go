@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// testDock returns a dock with a panel for each id
+func testDock(ids ...string) *DockDef {
+	var d *DockDef
+	panels := []*Panel{DockRef(&d)}
+	for _, id := range ids {
+		panels = append(panels, &Panel{Title: id})
+	}
+	Dock(&Theme{}, panels...)
+	return d
+}
+
+// dockString returns the tree as e.g. h([a b], v([c], [d])), with the tabs of each leaf in brackets
+func dockString(n *dockNode) string {
+	if len(n.Children) == 0 {
+		return "[" + strings.Join(n.Tabs, " ") + "]"
+	}
+	var s []string
+	for _, c := range n.Children {
+		s = append(s, dockString(c))
+	}
+	return n.Axis[:1] + "(" + strings.Join(s, ", ") + ")"
+}
+
+// leafOf returns the leaf with the panel id in its tabs
+func leafOf(n *dockNode, id string) *dockNode {
+	for _, t := range n.Tabs {
+		if t == id {
+			return n
+		}
+	}
+	for _, c := range n.Children {
+		if l := leafOf(c, id); l != nil {
+			return l
+		}
+	}
+	return nil
+}
+
+func TestDockRef(t *testing.T) {
+	d := testDock("a", "b")
+	if d == nil || len(d.order) != 2 || d.panels["a"] == nil {
+		t.Fatalf("got %+v", d)
+	}
+	if got := dockString(d.root); got != "h([a], [b])" {
+		t.Errorf("got %s", got)
+	}
+	if got := dockString(testDock("a").root); got != "[a]" {
+		t.Errorf("one panel: got %s", got)
+	}
+}
+
+func TestDockJSONRoundTrip(t *testing.T) {
+	d := testDock("a", "b", "c", "d")
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"axis":"horizontal","children":[{"tabs":["a"]},{"tabs":["b"]},{"tabs":["c"]},{"tabs":["d"]}]}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	d.dock(d.panels["a"], leafOf(d.root, "a"), leafOf(d.root, "b"), zoneCenter)
+	d.dock(d.panels["c"], leafOf(d.root, "c"), leafOf(d.root, "d"), zoneBottom)
+	d.root.Active = 1
+	data, err = json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := testDock("a", "b", "c", "d")
+	if err := json.Unmarshal(data, e); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dockString(e.root), "h([b a], v([d], [c]))"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	again, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("got %s, want %s", again, data)
+	}
+}
+
+func TestDockUnmarshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		ratios []float32
+		err    bool
+	}{
+		{"unknown panel", `{"tabs":["a","x","b","c"]}`, "[a b c]", nil, false},
+		{"missing panels", `{"axis":"vertical","children":[{"tabs":["b"]},{"tabs":["a"]}]}`, "v([b c], [a])", nil, false},
+		{"duplicate panel", `{"axis":"horizontal","children":[{"tabs":["a","b"]},{"tabs":["b","c"]}]}`, "h([a b], [c])", nil, false},
+		{"duplicate removes split", `{"axis":"vertical","children":[{"tabs":["a"]},{"tabs":["a"]}]}`, "[a b c]", nil, false},
+		{"ratios of removed children", `{"axis":"horizontal","ratios":[0.2,0.3,0.5],"children":[{"tabs":["a"]},{"tabs":["x"]},{"tabs":["b","c"]}]}`,
+			"h([a], [b c])", []float32{0.2, 0.5}, false},
+		{"only unknown panels", `{"tabs":["x"]}`, "[a b c]", nil, false},
+		{"empty", `{}`, "[a b c]", nil, false},
+		{"bad axis", `{"axis":"diagonal","children":[{"tabs":["a"]},{"tabs":["b"]}]}`, "", nil, true},
+		{"bad nested axis", `{"axis":"vertical","children":[{"tabs":["a"]},{"children":[{"tabs":["b"]}]}]}`, "", nil, true},
+		{"children and tabs", `{"axis":"vertical","tabs":["a"],"children":[{"tabs":["b"]}]}`, "", nil, true},
+		{"not json", `{"tabs":`, "", nil, true},
+	}
+	for _, tt := range tests {
+		d := testDock("a", "b", "c")
+		err := json.Unmarshal([]byte(tt.data), d)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			// The layout is kept on errors
+			if got := dockString(d.root); got != "h([a], [b], [c])" {
+				t.Errorf("%s: layout changed to %s", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := dockString(d.root); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if tt.ratios != nil && !equalRatios(d.root.Ratios, tt.ratios) {
+			t.Errorf("%s: got ratios %v, want %v", tt.name, d.root.Ratios, tt.ratios)
+		}
+	}
+	d := testDock("a", "b", "c")
+	if err := json.Unmarshal([]byte(`{"tabs":["a","b"],"active":7}`), d); err != nil || d.root.Active != 1 {
+		t.Errorf("active is not limited: %d, %v", d.root.Active, err)
+	}
+}
+
+func TestDockEdits(t *testing.T) {
+	type step struct {
+		panel, target string
+		zone          dockZone
+	}
+	tests := []struct {
+		name   string
+		panels []string
+		steps  []step
+		want   string
+		ratios []float32
+	}{
+		{"tab", []string{"a", "b", "c"}, []step{{"a", "b", zoneCenter}}, "h([b a], [c])", []float32{0.5, 0.5}},
+		{"right on same axis", []string{"a", "b", "c"}, []step{{"a", "c", zoneRight}}, "h([b], [c], [a])", []float32{0.5, 0.25, 0.25}},
+		{"left on same axis", []string{"a", "b", "c"}, []step{{"c", "a", zoneLeft}}, "h([c], [a], [b])", []float32{0.25, 0.25, 0.5}},
+		{"bottom splits target", []string{"a", "b", "c"}, []step{{"a", "b", zoneBottom}}, "h(v([b], [a]), [c])", []float32{0.5, 0.5}},
+		{"top splits target", []string{"a", "b", "c"}, []step{{"c", "b", zoneTop}}, "h([a], v([c], [b]))", []float32{0.5, 0.5}},
+		{"own leaf", []string{"a", "b", "c"}, []step{{"a", "a", zoneRight}, {"b", "b", zoneCenter}}, "h([a], [b], [c])", nil},
+		{"split own tabs", []string{"a", "b", "c"}, []step{{"a", "b", zoneCenter}, {"a", "b", zoneRight}}, "h([b], [a], [c])", nil},
+		{"empty split removed", []string{"a", "b", "c"}, []step{{"a", "b", zoneBottom}, {"b", "c", zoneCenter}}, "h([a], [c b])", []float32{0.5, 0.5}},
+		{"new root", []string{"a", "b"}, []step{{"b", "a", zoneBottom}}, "v([a], [b])", []float32{0.5, 0.5}},
+		{"tabs at root", []string{"a", "b"}, []step{{"b", "a", zoneCenter}, {"a", "b", zoneLeft}}, "h([a], [b])", []float32{0.5, 0.5}},
+	}
+	for _, tt := range tests {
+		d := testDock(tt.panels...)
+		for _, s := range tt.steps {
+			d.dock(d.panels[s.panel], leafOf(d.root, s.panel), leafOf(d.root, s.target), s.zone)
+		}
+		if got := dockString(d.root); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if tt.ratios != nil && !equalRatios(d.root.ratios(), tt.ratios) {
+			t.Errorf("%s: got ratios %v, want %v", tt.name, d.root.Ratios, tt.ratios)
+		}
+	}
+}
+
+func TestDockRemoveActive(t *testing.T) {
+	d := testDock("a", "b", "c")
+	d.dock(d.panels["b"], leafOf(d.root, "b"), leafOf(d.root, "a"), zoneCenter)
+	d.dock(d.panels["c"], leafOf(d.root, "c"), leafOf(d.root, "a"), zoneCenter)
+	leaf := d.root
+	if leaf.Active != 2 {
+		t.Fatalf("active is %d, want the docked tab", leaf.Active)
+	}
+	d.remove("c", leaf)
+	if got := dockString(d.root); got != "[a b]" || leaf.Active != 1 {
+		t.Errorf("got %s with active %d", got, leaf.Active)
+	}
+	// The last leaf is kept when it gets empty
+	d.remove("a", leaf)
+	d.remove("b", leaf)
+	if got := dockString(d.root); got != "[]" || leaf.Active != 0 {
+		t.Errorf("got %s with active %d", got, leaf.Active)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"gioui.org/io/event"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -22,7 +23,6 @@ type DropDownStyle struct {
 	Clickable
 	items           []string
 	itemHovered     []bool
-	outlineColor    color.NRGBA
 	listVisible     bool
 	inList          bool
 	list            Wid
@@ -40,7 +40,6 @@ func DropDown(th *Theme, index *int, items []string, options ...Option) layout.W
 	b := DropDownStyle{}
 	b.th = th
 	b.role = Canvas
-	b.outlineColor = th.Fg(Outline)
 	b.Font = &th.DefaultFont
 	b.index = index
 	b.items = items
@@ -131,13 +130,16 @@ func (b *DropDownStyle) Layout(gtx C) D {
 	}
 	if b.borderThickness > 0 {
 		if b.Focused() {
-			paintBorder(gtx, border, b.outlineColor, b.borderThickness*2, r)
+			paintBorder(gtx, border, b.th.Fg(Outline), b.borderThickness*2, r)
 		} else if b.Hovered() {
-			paintBorder(gtx, border, b.outlineColor, b.borderThickness*3/2, r)
+			paintBorder(gtx, border, b.th.Fg(Outline), b.borderThickness*3/2, r)
 		} else {
 			paintBorder(gtx, border, b.Fg(), b.th.BorderThickness, r)
 		}
 	}
+	if b.Focused() {
+		paintFocusRing(gtx, b.th, border, r)
+	}
 	drawTextMacro.Add(gtx.Ops)
 
 	// Draw icon using foreground color
@@ -197,25 +199,7 @@ func (b *DropDownStyle) Layout(gtx C) D {
 		}
 
 		dropdownMacro := op.Record(gtx.Ops)
-
-		// Fill background and draw list
-		cl := clip.Rect{Max: listClipRect.Max}.Push(gtx.Ops)
-		paint.Fill(gtx.Ops, b.th.Bg(Canvas))
-		theListMacro.Add(gtx.Ops)
-		cl.Pop()
-
-		// Handle mouse enter/leave into list
-		cl = clip.Rect(listClipRect).Push(gtx.Ops)
-		pass := pointer.PassOp{}.Push(gtx.Ops)
-		pointer.InputOp{
-			Tag:   &b.role,
-			Types: pointer.Enter | pointer.Leave,
-		}.Add(gtx.Ops)
-		cl.Pop()
-		pass.Pop()
-
-		// Draw a border around all options
-		paintBorder(gtx, listClipRect, b.th.Fg(Outline), b.th.BorderThickness, 0)
+		drawPopup(gtx, b.th, listClipRect.Max, theListMacro, &b.role)
 		// Save and defer execution
 		dropDownListCall := dropdownMacro.Stop()
 		op.Defer(gtx.Ops, dropDownListCall)
@@ -225,12 +209,38 @@ func (b *DropDownStyle) Layout(gtx C) D {
 	}
 	pointer.CursorPointer.Add(gtx.Ops)
 	b.SetupEventHandlers(gtx, dims.Size)
+	registerFocus(gtx, b, b.tabIndex)
 
 	return D{Size: image.Pt(
 		gtx.Constraints.Max.X,
 		border.Max.Y-border.Min.Y+gtx.Dp(b.padding.Bottom+b.padding.Top))}
 }
 
+// drawPopup fills the background of a popup with the given size, draws the content
+// and a border around it. The tag receives pointer Enter and Leave events.
+// It is used by DropDown and DatePicker, and must be called in a deferred macro.
+func drawPopup(gtx C, th *Theme, size image.Point, content op.CallOp, tag event.Tag) {
+	rect := image.Rectangle{Max: size}
+	// Fill background and draw content
+	cl := clip.Rect(rect).Push(gtx.Ops)
+	paint.Fill(gtx.Ops, th.Bg(Canvas))
+	content.Add(gtx.Ops)
+	cl.Pop()
+
+	// Handle mouse enter/leave into the popup
+	cl = clip.Rect(rect).Push(gtx.Ops)
+	pass := pointer.PassOp{}.Push(gtx.Ops)
+	pointer.InputOp{
+		Tag:   tag,
+		Types: pointer.Enter | pointer.Leave,
+	}.Add(gtx.Ops)
+	pass.Pop()
+	cl.Pop()
+
+	// Draw a border around the content
+	paintBorder(gtx, rect, th.Fg(Outline), th.BorderThickness, 0)
+}
+
 func (b *DropDownStyle) setHovered(h int) {
 	for i := 0; i < len(b.itemHovered); i++ {
 		b.itemHovered[i] = false
@@ -6,6 +6,7 @@ import (
 	"image"
 	"image/color"
 
+	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 
 	"gioui.org/op"
@@ -22,7 +23,6 @@ type EditDef struct {
 	widget.Editor
 	hovered         bool
 	outlineColor    color.NRGBA
-	selectionColor  color.NRGBA
 	CharLimit       uint
 	label           string
 	value           *string
@@ -31,20 +31,15 @@ type EditDef struct {
 	wasFocused      bool
 	minHeight       int
 	maxHeight       int
+	// painter replaces the default single color text painting when set
+	painter func(gtx C)
+	request focusRequest
 }
 
 // Edit will return a widget (layout function) for a text editor
 func Edit(th *Theme, options ...Option) func(gtx C) D {
 	e := new(EditDef)
-	e.th = th
-	e.Font = &th.DefaultFont
-	e.labelSize = th.TextSize * 8
-	e.SingleLine = true
-	e.borderThickness = th.BorderThickness
-	e.width = unit.Dp(5000) // Default to max width that is possible
-	e.padding = th.OutsidePadding
-	e.outlineColor = th.Fg(Outline)
-	e.selectionColor = MulAlpha(th.Bg(Primary), 60)
+	e.setDefaults(th)
 	// Read in options to change from default values to something else.
 	for _, option := range options {
 		option.apply(e)
@@ -57,6 +52,16 @@ func Edit(th *Theme, options ...Option) func(gtx C) D {
 	}
 }
 
+func (e *EditDef) setDefaults(th *Theme) {
+	e.th = th
+	e.Font = &th.DefaultFont
+	e.labelSize = th.TextSize * 8
+	e.SingleLine = true
+	e.borderThickness = th.BorderThickness
+	e.width = unit.Dp(5000) // Default to max width that is possible
+	e.padding = th.OutsidePadding
+}
+
 func (e *EditDef) updateValue() {
 	if !e.Focused() && e.value != nil {
 		current := e.Text()
@@ -137,16 +142,29 @@ func (e *EditDef) Layout(gtx C) D {
 	dims.Size.X = gtx.Constraints.Max.X
 	dims.Size.Y += gtx.Dp(e.th.InsidePadding.Top + e.th.InsidePadding.Bottom + e.padding.Top + e.padding.Bottom)
 
+	switch e.request.take(gtx) {
+	case focusRequested:
+		e.Editor.Focus()
+	case blurRequested:
+		if e.Focused() {
+			key.FocusOp{}.Add(gtx.Ops)
+		}
+	}
+
 	macro = op.Record(gtx.Ops)
 	o := op.Offset(image.Pt(0, gtx.Dp(e.th.InsidePadding.Top))).Push(gtx.Ops)
 	gtx.Constraints.Min.X = gtx.Constraints.Max.X
 	dims = e.Editor.Layout(gtx, e.th.Shaper, *e.Font, e.th.TextSize, func(gtx C) D {
 		disabled := gtx.Queue == nil
 		if e.Editor.Len() > 0 || e.Focused() {
-			paint.ColorOp{Color: e.selectionColor}.Add(gtx.Ops)
+			paint.ColorOp{Color: MulAlpha(e.th.Bg(Primary), 60)}.Add(gtx.Ops)
 			e.Editor.PaintSelection(gtx)
-			paint.ColorOp{Color: e.Fg()}.Add(gtx.Ops)
-			e.Editor.PaintText(gtx)
+			if e.painter != nil {
+				e.painter(gtx)
+			} else {
+				paint.ColorOp{Color: e.Fg()}.Add(gtx.Ops)
+				e.Editor.PaintText(gtx)
+			}
 		} else {
 			callHint.Add(gtx.Ops)
 		}
@@ -171,14 +189,22 @@ func (e *EditDef) Layout(gtx C) D {
 		paint.FillShape(gtx.Ops, e.th.Bg(Canvas), clip.UniformRRect(border, r).Op(gtx.Ops))
 	}
 	if e.borderThickness > 0 {
+		outline := e.outlineColor
+		if outline == (color.NRGBA{}) {
+			outline = e.th.Fg(Outline)
+		}
 		if e.Focused() {
-			paintBorder(gtx, border, e.outlineColor, e.th.BorderThickness*2, r)
+			paintBorder(gtx, border, outline, e.th.BorderThickness*2, r)
 		} else if e.hovered {
-			paintBorder(gtx, border, e.outlineColor, e.th.BorderThickness*3/2, r)
+			paintBorder(gtx, border, outline, e.th.BorderThickness*3/2, r)
 		} else {
-			paintBorder(gtx, border, e.outlineColor, e.th.BorderThickness, r)
+			paintBorder(gtx, border, outline, e.th.BorderThickness, r)
 		}
 	}
+	if e.Focused() {
+		paintFocusRing(gtx, e.th, border, r)
+	}
+	registerFocus(gtx, e, e.tabIndex)
 
 	defer pointer.PassOp{}.Push(gtx.Ops).Pop()
 	eventArea := clip.Rect(border).Push(gtx.Ops)
@@ -207,6 +233,16 @@ func (e *EditDef) Layout(gtx C) D {
 		border.Max.Y-border.Min.Y+gtx.Dp(e.padding.Bottom+e.padding.Top))}
 }
 
+// Focus moves the keyboard focus to the edit when it is drawn next time
+func (e *EditDef) Focus() {
+	e.request.set(focusRequested)
+}
+
+// Blur removes the keyboard focus from the edit
+func (e *EditDef) Blur() {
+	e.request.set(blurRequested)
+}
+
 // EditOption is options specific to Edits
 type EditOption func(w *EditDef)
 
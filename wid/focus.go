@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"sort"
+	"time"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// Focuser is implemented by widgets that can take the keyboard focus.
+// Use the FocusRef() option to get the widget from its constructor.
+type Focuser interface {
+	// Focus moves the keyboard focus to the widget when it is drawn next time
+	Focus()
+	// Blur removes the keyboard focus from the widget, if it has it
+	Blur()
+	// Focused returns true if the widget has the keyboard focus
+	Focused() bool
+}
+
+// focusRequest is a pending Focus() or Blur() call, handled by the next layout.
+type focusRequest uint8
+
+const (
+	noRequest focusRequest = iota
+	focusRequested
+	blurRequested
+)
+
+type focusEntry struct {
+	w        Focuser
+	tabIndex int
+}
+
+var (
+	// focusList is the focusable widgets drawn in the current frame, in drawing order
+	focusList []focusEntry
+	// lastFocus is the list from the previous frame
+	lastFocus []focusEntry
+	// focusFrame is the time of the frame in focusList. The lists are guarded by GuiLock.
+	focusFrame time.Time
+	tabTag     struct{}
+)
+
+// FocusRef is an option that stores the widget in f, so that the application
+// can call Focus() and Blur() later.
+func FocusRef(f *Focuser) BaseOption {
+	return func(w BaseIf) {
+		if o, ok := w.(Focuser); ok {
+			*f = o
+		}
+	}
+}
+
+// AutoFocus gives the widget the keyboard focus the first time it is drawn.
+func AutoFocus() BaseOption {
+	return func(w BaseIf) {
+		if o, ok := w.(Focuser); ok {
+			o.Focus()
+		}
+	}
+}
+
+// FocusFirst returns the form with autofocus. The first widget of the form in Tab order
+// gets the keyboard focus the first time the form is drawn, unless one of its widgets
+// already has the focus, e.g. from the AutoFocus() option.
+func FocusFirst(form layout.Widget) layout.Widget {
+	done := false
+	return func(gtx C) D {
+		GuiLock.Lock()
+		rotateFocus(gtx.Now)
+		start := len(focusList)
+		GuiLock.Unlock()
+		dims := form(gtx)
+		if done || gtx.Queue == nil {
+			return dims
+		}
+		done = true
+		GuiLock.RLock()
+		list := append([]focusEntry(nil), focusList[start:]...)
+		GuiLock.RUnlock()
+		order := tabOrder(list)
+		for _, w := range order {
+			if w.Focused() {
+				return dims
+			}
+		}
+		if len(order) > 0 {
+			order[0].Focus()
+		}
+		return dims
+	}
+}
+
+// TabIndex sets the position of the widget in the Tab order. Widgets with a positive
+// index come first, lowest index first, followed by the widgets with index 0 in drawing order.
+// Widgets with a negative index are skipped by Tab.
+func TabIndex(i int) BaseOption {
+	return func(w BaseIf) {
+		w.setTabIndex(i)
+	}
+}
+
+// FocusedWidget returns the focusable widget that has the focus, or nil.
+// Only widgets drawn in the last frame are found.
+func FocusedWidget() Focuser {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	for _, f := range lastFocus {
+		if f.w.Focused() {
+			return f.w
+		}
+	}
+	return nil
+}
+
+func (r *focusRequest) set(v focusRequest) {
+	GuiLock.Lock()
+	*r = v
+	GuiLock.Unlock()
+	Invalidate()
+}
+
+// take returns and clears the pending request. Requests for disabled widgets
+// are kept until they are enabled.
+func (r *focusRequest) take(gtx C) focusRequest {
+	if gtx.Queue == nil {
+		return noRequest
+	}
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	v := *r
+	*r = noRequest
+	return v
+}
+
+// apply adds a focus operation for a pending request. A key.InputOp for tag must be
+// added in the same frame.
+func (r *focusRequest) apply(gtx C, tag event.Tag, focused bool) {
+	switch r.take(gtx) {
+	case focusRequested:
+		key.FocusOp{Tag: tag}.Add(gtx.Ops)
+	case blurRequested:
+		if focused {
+			key.FocusOp{}.Add(gtx.Ops)
+		}
+	}
+}
+
+// rotateFocus starts a new focus list when a new frame is drawn, keeping the
+// list of the previous frame in lastFocus. It must be called with GuiLock held.
+func rotateFocus(now time.Time) {
+	if !now.Equal(focusFrame) {
+		focusFrame = now
+		lastFocus, focusList = focusList, lastFocus[:0]
+	}
+}
+
+// registerFocus adds an enabled widget to the Tab order of the current frame
+func registerFocus(gtx C, w Focuser, tabIndex int) {
+	if gtx.Queue != nil {
+		GuiLock.Lock()
+		rotateFocus(gtx.Now)
+		focusList = append(focusList, focusEntry{w: w, tabIndex: tabIndex})
+		GuiLock.Unlock()
+	}
+}
+
+// tabOrder returns the widgets that can be reached by Tab, in Tab order
+func tabOrder(list []focusEntry) []Focuser {
+	var order []focusEntry
+	found := make(map[Focuser]bool)
+	for _, f := range list {
+		if f.tabIndex >= 0 && !found[f.w] {
+			found[f.w] = true
+			order = append(order, f)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i].tabIndex, order[j].tabIndex
+		return a > 0 && (b == 0 || a < b)
+	})
+	widgets := make([]Focuser, len(order))
+	for i := range order {
+		widgets[i] = order[i].w
+	}
+	return widgets
+}
+
+// handleFocus is called by RunWithContext before the form is drawn. The default
+// Tab handling of gio is replaced when any widget has a TabIndex.
+// Applications with their own event loop get the focus list, but not Tab ordering.
+func handleFocus(gtx C) {
+	GuiLock.Lock()
+	rotateFocus(gtx.Now)
+	list := append([]focusEntry(nil), lastFocus...)
+	GuiLock.Unlock()
+	for _, e := range gtx.Events(&tabTag) {
+		if e, ok := e.(key.Event); ok && e.State == key.Press && e.Name == key.NameTab {
+			moveFocus(list, !e.Modifiers.Contain(key.ModShift))
+		}
+	}
+	if gtx.Queue == nil {
+		return
+	}
+	for _, f := range list {
+		if f.tabIndex != 0 {
+			key.InputOp{Tag: &tabTag, Keys: "(Shift)-⇥"}.Add(gtx.Ops)
+			return
+		}
+	}
+}
+
+// moveFocus focuses the next or previous widget in Tab order
+func moveFocus(list []focusEntry, forward bool) {
+	order := tabOrder(list)
+	n := len(order)
+	if n == 0 {
+		return
+	}
+	cur := -1
+	for i, w := range order {
+		if w.Focused() {
+			cur = i
+		}
+	}
+	next := 0
+	switch {
+	case cur < 0 && !forward:
+		next = n - 1
+	case cur >= 0 && forward:
+		next = (cur + 1) % n
+	case cur >= 0:
+		next = (cur + n - 1) % n
+	}
+	order[next].Focus()
+}
+
+// paintFocusRing draws the focus ring of the theme outside rect, which has corner radius rr
+func paintFocusRing(gtx C, th *Theme, rect image.Rectangle, rr int) {
+	col := th.FocusRingColor
+	if col.A == 0 {
+		col = th.Bg(Primary)
+	}
+	if gtx.Queue == nil {
+		col = Disabled(col)
+	}
+	width := gtx.Dp(th.FocusRingWidth)
+	d := gtx.Dp(th.FocusRingGap) + width/2
+	paint.FillShape(gtx.Ops, col,
+		clip.Stroke{
+			Path:  clip.UniformRRect(rect.Inset(-d), rr+d).Path(gtx.Ops),
+			Width: float32(width),
+		}.Op())
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"testing"
+	"time"
+
+	"gioui.org/io/event"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+type testFocuser struct {
+	name    string
+	focused bool
+}
+
+func (f *testFocuser) Focus()        { f.focused = true }
+func (f *testFocuser) Blur()         { f.focused = false }
+func (f *testFocuser) Focused() bool { return f.focused }
+
+type testQueue struct{}
+
+func (testQueue) Events(event.Tag) []event.Event { return nil }
+
+func TestTabOrder(t *testing.T) {
+	a, b, c, d := &testFocuser{name: "a"}, &testFocuser{name: "b"}, &testFocuser{name: "c"}, &testFocuser{name: "d"}
+	list := []focusEntry{{a, 0}, {b, 2}, {c, -1}, {d, 1}, {a, 0}}
+	got := tabOrder(list)
+	want := []Focuser{d, b, a}
+	if len(got) != len(want) {
+		t.Fatalf("got %d widgets, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%d: got %s, want %s", i, got[i].(*testFocuser).name, want[i].(*testFocuser).name)
+		}
+	}
+}
+
+func TestFocusListPerFrame(t *testing.T) {
+	var ops op.Ops
+	a, b := &testFocuser{name: "a"}, &testFocuser{name: "b"}
+	form := FocusFirst(func(gtx C) D {
+		registerFocus(gtx, a, -1)
+		registerFocus(gtx, b, 0)
+		return D{}
+	})
+	now := time.Unix(1000, 0)
+	for i := 0; i < 3; i++ {
+		// An application with its own event loop only draws the form
+		gtx := layout.Context{Ops: &ops, Now: now.Add(time.Duration(i) * time.Second), Queue: testQueue{}}
+		form(gtx)
+	}
+	GuiLock.RLock()
+	n, last := len(focusList), len(lastFocus)
+	GuiLock.RUnlock()
+	if n != 2 || last != 2 {
+		t.Errorf("focus lists have %d and %d entries, want 2", n, last)
+	}
+	if a.focused || !b.focused {
+		t.Errorf("FocusFirst did not focus the first widget in Tab order")
+	}
+	b.Blur()
+	form(layout.Context{Ops: &ops, Now: now.Add(5 * time.Second), Queue: testQueue{}})
+	if b.focused {
+		t.Errorf("FocusFirst focused a widget again")
+	}
+}
@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueFormat holds the formatting options for values shown in labels
+type valueFormat struct {
+	thousands  rune
+	si         bool
+	eng        bool
+	unit       string
+	percent    bool
+	width      int
+	timeLayout string
+	boolText   [2]string
+}
+
+var siPrefixes = []string{"p", "n", "µ", "m", "", "k", "M", "G", "T"}
+
+// Thousands will group the digits of numbers in thousands, separated by sep.
+func Thousands(sep rune) LabelOption {
+	return func(d *LabelDef) {
+		d.format.thousands = sep
+	}
+}
+
+// SI will scale numbers to the range 1..999 and add an SI prefix (p, n, µ, m, k, M, G, T).
+// Use with Unit(), like Label(th, &voltage, SI(), Unit("V")), giving "1.23 kV" with Dp(2).
+func SI() LabelOption {
+	return func(d *LabelDef) {
+		d.format.si = true
+	}
+}
+
+// Eng will show numbers in engineering notation, with an exponent that is a multiple of 3.
+func Eng() LabelOption {
+	return func(d *LabelDef) {
+		d.format.eng = true
+	}
+}
+
+// Unit is appended to the value, separated by a space.
+func Unit(u string) LabelOption {
+	return func(d *LabelDef) {
+		d.format.unit = u
+	}
+}
+
+// Percent will show the value multiplied by 100 with a % sign, so 0.5 is shown as 50%.
+func Percent() LabelOption {
+	return func(d *LabelDef) {
+		d.format.percent = true
+	}
+}
+
+// FixedWidth pads the value with spaces on the left to n characters.
+// The values will only line up when a mono font is used.
+func FixedWidth(n int) LabelOption {
+	return func(d *LabelDef) {
+		d.format.width = n
+	}
+}
+
+// TimeLayout sets the layout used for time.Time values, like time.Kitchen.
+// For time.Duration values, the layout is used for the duration since midnight,
+// so "15:04:05" shows hours, minutes and seconds (for durations below 24 hours).
+func TimeLayout(layout string) LabelOption {
+	return func(d *LabelDef) {
+		d.format.timeLayout = layout
+	}
+}
+
+// BoolText sets the text shown for false and true values.
+func BoolText(off, on string) LabelOption {
+	return func(d *LabelDef) {
+		d.format.boolText = [2]string{off, on}
+	}
+}
+
+// value returns the formatted value with dp decimals.
+func (f *valueFormat) value(v any, dp int) string {
+	var s string
+	numeric := false
+	switch x := v.(type) {
+	case string:
+		s = x
+	case int:
+		if f.percent || f.si || f.eng {
+			s = f.number(float64(x), dp, 64, true)
+		} else {
+			s = f.integer(int64(x))
+		}
+		numeric = true
+	case float64:
+		s, numeric = f.number(x, dp, 64, false), true
+	case float32:
+		s, numeric = f.number(float64(x), dp, 32, false), true
+	case bool:
+		s = strconv.FormatBool(x)
+		if f.boolText != [2]string{} {
+			s = f.boolText[0]
+			if x {
+				s = f.boolText[1]
+			}
+		}
+	case time.Duration:
+		s = f.duration(x, dp)
+	case time.Time:
+		layout := f.timeLayout
+		if layout == "" {
+			layout = "2006-01-02 15:04:05"
+		}
+		s = x.Format(layout)
+	case fmt.Stringer:
+		s = x.String()
+	default:
+		s = fmt.Sprintf("%v", x)
+	}
+	// Numbers have the unit added by number()
+	if f.unit != "" && !numeric {
+		s += " " + f.unit
+	}
+	if f.width > 0 {
+		s = fmt.Sprintf("%*s", f.width, s)
+	}
+	return s
+}
+
+func (f *valueFormat) duration(d time.Duration, dp int) string {
+	if f.timeLayout != "" {
+		return time.Time{}.Add(d).Format(f.timeLayout)
+	}
+	// Round to dp decimals on the seconds, but keep the resolution of short durations
+	if d >= time.Second || d <= -time.Second {
+		d = d.Round(time.Second / time.Duration(math.Pow10(Clamp(dp, 0, 9))))
+	}
+	return d.String()
+}
+
+// number formats x with the scaling, grouping and suffix options.
+func (f *valueFormat) number(x float64, dp int, bitSize int, isInt bool) string {
+	suffix := ""
+	if f.percent {
+		x *= 100
+		suffix = "%"
+	}
+	exp := 0
+	if (f.si || f.eng) && x != 0 && !math.IsInf(x, 0) && !math.IsNaN(x) {
+		exp = int(math.Floor(math.Log10(math.Abs(x))/3)) * 3
+		exp = Clamp(exp, -12, 12)
+		// Values rounding up to 1000 are moved to the next prefix
+		scale := math.Pow10(Clamp(dp, 0, 15))
+		if math.Abs(math.Round(x/math.Pow10(exp)*scale)/scale) >= 1000 && exp < 12 {
+			exp += 3
+		}
+		x /= math.Pow10(exp)
+		isInt = isInt && exp == 0
+	}
+	var s string
+	if isInt {
+		s = strconv.FormatInt(int64(x), 10)
+	} else {
+		s = strconv.FormatFloat(x, 'f', dp, bitSize)
+	}
+	if f.thousands != 0 {
+		s = groupThousands(s, f.thousands)
+	}
+	switch {
+	case f.si:
+		if p := siPrefixes[exp/3+4] + f.unit; p != "" {
+			suffix += " " + p
+		}
+		return s + suffix
+	case f.eng && exp != 0:
+		s += "e" + strconv.Itoa(exp)
+	}
+	if f.unit != "" {
+		suffix += " " + f.unit
+	}
+	return s + suffix
+}
+
+// integer formats x without converting it to float64, so large values keep all digits.
+func (f *valueFormat) integer(x int64) string {
+	s := strconv.FormatInt(x, 10)
+	if f.thousands != 0 {
+		s = groupThousands(s, f.thousands)
+	}
+	if f.unit != "" {
+		s += " " + f.unit
+	}
+	return s
+}
+
+// groupThousands inserts sep between each group of three digits in the integer part of s
+func groupThousands(s string, sep rune) string {
+	start := 0
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		start = 1
+	}
+	end := strings.IndexByte(s, '.')
+	if end < 0 {
+		end = len(s)
+	}
+	var b strings.Builder
+	b.WriteString(s[:start])
+	for i := start; i < end; i++ {
+		if i > start && (end-i)%3 == 0 {
+			b.WriteRune(sep)
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteString(s[end:])
+	return b.String()
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValueFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		f    valueFormat
+		v    any
+		dp   int
+		want string
+	}{
+		{"string", valueFormat{}, "abc", 2, "abc"},
+		{"int", valueFormat{}, 1234567, 2, "1234567"},
+		{"large int", valueFormat{}, math.MaxInt64, 2, "9223372036854775807"},
+		{"int thousands", valueFormat{thousands: ','}, -1234567, 2, "-1,234,567"},
+		{"int unit", valueFormat{unit: "kg"}, 12, 2, "12 kg"},
+		{"float", valueFormat{}, 3.14159, 2, "3.14"},
+		{"float32", valueFormat{}, float32(0.1), 3, "0.100"},
+		{"float thousands", valueFormat{thousands: ' '}, 1234567.891, 2, "1 234 567.89"},
+		{"small thousands", valueFormat{thousands: ','}, 999.5, 1, "999.5"},
+		{"percent", valueFormat{percent: true}, 0.256, 1, "25.6%"},
+		{"int percent", valueFormat{percent: true}, 2, 0, "200%"},
+		{"si", valueFormat{si: true, unit: "Hz"}, 1500.0, 1, "1.5 kHz"},
+		{"si small", valueFormat{si: true, unit: "F"}, 4.7e-6, 1, "4.7 µF"},
+		{"si no prefix", valueFormat{si: true, unit: "V"}, 5.0, 1, "5.0 V"},
+		{"si rounds up", valueFormat{si: true}, 999.96, 1, "1.0 k"},
+		{"si int", valueFormat{si: true}, 2000000, 0, "2 M"},
+		{"eng", valueFormat{eng: true}, 12345.0, 2, "12.35e3"},
+		{"eng small", valueFormat{eng: true}, 0.5, 0, "500e-3"},
+		{"eng one", valueFormat{eng: true}, 1.0, 1, "1.0"},
+		{"zero si", valueFormat{si: true}, 0.0, 1, "0.0"},
+		{"nan si", valueFormat{si: true}, math.NaN(), 1, "NaN"},
+		{"width", valueFormat{width: 6}, 42, 0, "    42"},
+		{"bool", valueFormat{}, true, 0, "true"},
+		{"bool text", valueFormat{boolText: [2]string{"off", "on"}}, false, 0, "off"},
+		{"duration", valueFormat{}, 1500 * time.Millisecond, 0, "2s"},
+		{"short duration", valueFormat{}, 1500 * time.Microsecond, 0, "1.5ms"},
+		{"duration layout", valueFormat{timeLayout: "15:04:05"}, 90 * time.Minute, 0, "01:30:00"},
+		{"time", valueFormat{}, time.Date(2023, 2, 1, 13, 4, 5, 0, time.UTC), 0, "2023-02-01 13:04:05"},
+		{"string unit", valueFormat{unit: "pcs"}, "many", 0, "many pcs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.value(tt.v, tt.dp); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupThousands(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"", ""},
+		{"1", "1"},
+		{"123", "123"},
+		{"1234", "1,234"},
+		{"123456", "123,456"},
+		{"-1234567", "-1,234,567"},
+		{"+1234", "+1,234"},
+		{"12345.6789", "12,345.6789"},
+		{"NaN", "NaN"},
+	}
+	for _, tt := range tests {
+		if got := groupThousands(tt.s, ','); got != tt.want {
+			t.Errorf("groupThousands(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
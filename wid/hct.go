@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image/color"
+	"math"
+	"sync"
+)
+
+// Hct is a color in the hue, chroma and tone color space used by Material 3.
+// Hue is 0..360 degrees, chroma is the colorfulness from 0 (gray) and up to about 130,
+// and tone is the perceived lightness (L*) from 0 (black) to 100 (white).
+// The hue and chroma are from the CAM16 color appearance model.
+// See https://material.io/blog/science-of-color-design
+type Hct struct {
+	Hue    float64
+	Chroma float64
+	Tone   float64
+}
+
+// TonalPalette is all the tones of one hue and chroma
+type TonalPalette struct {
+	Hue    float64
+	Chroma float64
+}
+
+// cam16 is a color in the CAM16 color appearance model
+type cam16 struct {
+	hue, chroma, j      float64
+	jstar, astar, bstar float64
+}
+
+// vc is the CAM16 viewing conditions for the standard sRGB environment:
+// a D65 white point, an adapting luminance of 200/π * Y(L*=50) and an average surround.
+var vc = func() (v struct {
+	n, aw, nbb, ncb, c, nc, fl, flRoot, z float64
+	rgbD                                  [3]float64
+}) {
+	white := [3]float64{95.047, 100, 108.883}
+	adapting := 200 / math.Pi * yFromLstar(50) / 100
+	rW, gW, bW := cat16(white[0], white[1], white[2])
+	f := 1.0
+	v.c = 0.69
+	v.nc = f
+	d := Clamp(f*(1-(1/3.6)*math.Exp((-adapting-42)/92)), 0, 1)
+	v.rgbD = [3]float64{d*100/rW + 1 - d, d*100/gW + 1 - d, d*100/bW + 1 - d}
+	k := 1 / (5*adapting + 1)
+	k4 := k * k * k * k
+	v.fl = k4*adapting + 0.1*(1-k4)*(1-k4)*math.Cbrt(5*adapting)
+	v.flRoot = math.Pow(v.fl, 0.25)
+	v.n = yFromLstar(50) / white[1]
+	v.z = 1.48 + math.Sqrt(v.n)
+	v.nbb = 0.725 / math.Pow(v.n, 0.2)
+	v.ncb = v.nbb
+	var rgbA [3]float64
+	for i, w := range [3]float64{rW, gW, bW} {
+		af := math.Pow(v.fl*v.rgbD[i]*w/100, 0.42)
+		rgbA[i] = 400 * af / (af + 27.13)
+	}
+	v.aw = (2*rgbA[0] + rgbA[1] + 0.05*rgbA[2]) * v.nbb
+	return v
+}()
+
+// toneCache holds colors calculated by Tone(), as solving HCT is slow
+var toneCache = struct {
+	sync.Mutex
+	m map[toneKey]color.NRGBA
+}{m: map[toneKey]color.NRGBA{}}
+
+type toneKey struct {
+	c    color.NRGBA
+	tone int
+}
+
+const maxToneCache = 4096
+
+// Tone returns the color with the hue and chroma of c, with the given tone from 0 (black)
+// to 100 (white). This is the Material 3 tonal palette of c.
+// See: https://m3.material.io/styles/color/the-color-system/key-colors-tones
+func Tone(c color.NRGBA, tone int) color.NRGBA {
+	c.A = 255
+	key := toneKey{c, tone}
+	toneCache.Lock()
+	defer toneCache.Unlock()
+	if col, ok := toneCache.m[key]; ok {
+		return col
+	}
+	h := HctFromColor(c)
+	col := TonalPalette{Hue: h.Hue, Chroma: h.Chroma}.Tone(float64(tone))
+	if len(toneCache.m) >= maxToneCache {
+		toneCache.m = map[toneKey]color.NRGBA{}
+	}
+	toneCache.m[key] = col
+	return col
+}
+
+// Tone returns the color of the palette with the given tone, from 0 to 100
+func (p TonalPalette) Tone(tone float64) color.NRGBA {
+	return Hct{Hue: p.Hue, Chroma: p.Chroma, Tone: tone}.Color()
+}
+
+// PalletFromSeed returns the Material 3 key colors derived from one seed color.
+// The secondary and neutral colors have the hue of the seed with less chroma,
+// and the tertiary color is 60 degrees away.
+func PalletFromSeed(seed color.NRGBA) Pallet {
+	h := HctFromColor(seed)
+	key := func(hue, chroma float64) color.NRGBA {
+		return TonalPalette{Hue: math.Mod(hue, 360), Chroma: chroma}.Tone(50)
+	}
+	return Pallet{
+		PrimaryColor:        key(h.Hue, math.Max(48, h.Chroma)),
+		SecondaryColor:      key(h.Hue, 16),
+		TertiaryColor:       key(h.Hue+60, 24),
+		ErrorColor:          key(25, 84),
+		NeutralColor:        key(h.Hue, 4),
+		NeutralVariantColor: key(h.Hue, 8),
+	}
+}
+
+// HctFromColor returns the hue, chroma and tone of c
+func HctFromColor(c color.NRGBA) Hct {
+	cam := camFromColor(c)
+	return Hct{Hue: cam.hue, Chroma: cam.chroma, Tone: lstarFromColor(c)}
+}
+
+// Color returns the sRGB color closest to h. When the chroma can not be shown
+// at the given tone, the hue and tone are kept and the chroma is reduced.
+func (h Hct) Color() color.NRGBA {
+	const chromaEndpoint = 0.4
+	tone := Clamp(h.Tone, 0, 100)
+	hue := math.Mod(math.Mod(h.Hue, 360)+360, 360)
+	if h.Chroma < 1 || math.Round(tone) <= 0 || math.Round(tone) >= 100 {
+		return grayFromLstar(tone)
+	}
+	// Binary search for the highest chroma that can be shown, starting with the requested
+	high, mid, low := h.Chroma, h.Chroma, 0.0
+	var answer *cam16
+	for first := true; math.Abs(low-high) >= chromaEndpoint; first = false {
+		cam := findCamByJ(hue, mid, tone)
+		if first && cam != nil {
+			return cam.color()
+		}
+		if cam == nil {
+			high = mid
+		} else {
+			answer, low = cam, mid
+		}
+		mid = low + (high-low)/2
+	}
+	if answer == nil {
+		return grayFromLstar(tone)
+	}
+	return answer.color()
+}
+
+// findCamByJ searches for the CAM16 lightness J giving the tone, with the hue and chroma.
+// It returns nil when the color is outside sRGB.
+func findCamByJ(hue, chroma, tone float64) *cam16 {
+	const (
+		lightnessEndpoint = 0.01
+		maxDL             = 0.2
+		maxDE             = 1.0
+	)
+	low, high := 0.0, 100.0
+	bestDL, bestDE := 1000.0, 1000.0
+	var best *cam16
+	for math.Abs(low-high) > lightnessEndpoint {
+		mid := low + (high-low)/2
+		clipped := camFromJch(mid, chroma, hue).color()
+		l := lstarFromColor(clipped)
+		dL := math.Abs(tone - l)
+		if dL < maxDL {
+			cam := camFromColor(clipped)
+			dE := cam.distance(camFromJch(cam.j, cam.chroma, hue))
+			if dE <= maxDE && dE <= bestDE {
+				bestDL, bestDE = dL, dE
+				c := cam
+				best = &c
+			}
+		}
+		if bestDL == 0 && bestDE == 0 {
+			break
+		}
+		if l < tone {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return best
+}
+
+// camFromColor converts a sRGB color to CAM16
+func camFromColor(c color.NRGBA) cam16 {
+	r, g, b := linearized(c.R), linearized(c.G), linearized(c.B)
+	x := 0.41233895*r + 0.35762064*g + 0.18051042*b
+	y := 0.2126*r + 0.7152*g + 0.0722*b
+	z := 0.01932141*r + 0.11916382*g + 0.95034478*b
+	rC, gC, bC := cat16(x, y, z)
+	var rgbA [3]float64
+	for i, v := range [3]float64{rC, gC, bC} {
+		d := vc.rgbD[i] * v
+		af := math.Pow(vc.fl*math.Abs(d)/100, 0.42)
+		rgbA[i] = sign(d) * 400 * af / (af + 27.13)
+	}
+	rA, gA, bA := rgbA[0], rgbA[1], rgbA[2]
+	a := (11*rA - 12*gA + bA) / 11
+	bb := (rA + gA - 2*bA) / 9
+	u := (20*rA + 20*gA + 21*bA) / 20
+	p2 := (40*rA + 20*gA + bA) / 20
+	hue := math.Mod(math.Atan2(bb, a)*180/math.Pi+360, 360)
+	ac := p2 * vc.nbb
+	j := 100 * math.Pow(ac/vc.aw, vc.c*vc.z)
+	huePrime := hue
+	if hue < 20.14 {
+		huePrime += 360
+	}
+	eHue := 0.25 * (math.Cos(huePrime*math.Pi/180+2) + 3.8)
+	p1 := 50000.0 / 13 * eHue * vc.nc * vc.ncb
+	t := p1 * math.Hypot(a, bb) / (u + 0.305)
+	alpha := math.Pow(t, 0.9) * math.Pow(1.64-math.Pow(0.29, vc.n), 0.73)
+	return camFromJch(j, alpha*math.Sqrt(j/100), hue)
+}
+
+// camFromJch returns the CAM16 color with the given lightness, chroma and hue
+func camFromJch(j, chroma, hue float64) cam16 {
+	m := chroma * vc.flRoot
+	mstar := 1 / 0.0228 * math.Log(1+0.0228*m)
+	rad := hue * math.Pi / 180
+	return cam16{
+		hue:    hue,
+		chroma: chroma,
+		j:      j,
+		jstar:  (1 + 100*0.007) * j / (1 + 0.007*j),
+		astar:  mstar * math.Cos(rad),
+		bstar:  mstar * math.Sin(rad),
+	}
+}
+
+// distance is the CAM16-UCS color difference
+func (c cam16) distance(o cam16) float64 {
+	dJ, dA, dB := c.jstar-o.jstar, c.astar-o.astar, c.bstar-o.bstar
+	return 1.41 * math.Pow(math.Sqrt(dJ*dJ+dA*dA+dB*dB), 0.63)
+}
+
+// color converts the CAM16 color to sRGB, clipping it to the sRGB gamut
+func (c cam16) color() color.NRGBA {
+	alpha := 0.0
+	if c.chroma != 0 && c.j != 0 {
+		alpha = c.chroma / math.Sqrt(c.j/100)
+	}
+	t := math.Pow(alpha/math.Pow(1.64-math.Pow(0.29, vc.n), 0.73), 1/0.9)
+	rad := c.hue * math.Pi / 180
+	eHue := 0.25 * (math.Cos(rad+2) + 3.8)
+	ac := vc.aw * math.Pow(c.j/100, 1/vc.c/vc.z)
+	p1 := eHue * (50000.0 / 13) * vc.nc * vc.ncb
+	p2 := ac / vc.nbb
+	hSin, hCos := math.Sin(rad), math.Cos(rad)
+	gamma := 23 * (p2 + 0.305) * t / (23*p1 + 11*t*hCos + 108*t*hSin)
+	a, b := gamma*hCos, gamma*hSin
+	rgbA := [3]float64{
+		(460*p2 + 451*a + 288*b) / 1403,
+		(460*p2 - 891*a - 261*b) / 1403,
+		(460*p2 - 220*a - 6300*b) / 1403,
+	}
+	var rgbF [3]float64
+	for i, v := range rgbA {
+		base := math.Max(0, 27.13*math.Abs(v)/(400-math.Abs(v)))
+		rgbF[i] = sign(v) * (100 / vc.fl) * math.Pow(base, 1/0.42) / vc.rgbD[i]
+	}
+	x := 1.86206786*rgbF[0] - 1.01125463*rgbF[1] + 0.14918677*rgbF[2]
+	y := 0.38752654*rgbF[0] + 0.62144744*rgbF[1] - 0.00897398*rgbF[2]
+	z := -0.01584150*rgbF[0] - 0.03412294*rgbF[1] + 1.04996444*rgbF[2]
+	return color.NRGBA{
+		R: delinearized(3.2413774792388685*x - 1.5376652402851851*y - 0.49885366846268053*z),
+		G: delinearized(-0.9691452513005321*x + 1.8758853451067872*y + 0.04156585616912061*z),
+		B: delinearized(0.05562093689691305*x - 0.20395524564742123*y + 1.0571799111220335*z),
+		A: 255,
+	}
+}
+
+// cat16 converts XYZ to the CAT16 cone responses
+func cat16(x, y, z float64) (float64, float64, float64) {
+	return 0.401288*x + 0.650173*y - 0.051461*z,
+		-0.250268*x + 1.204414*y + 0.045854*z,
+		-0.002079*x + 0.048952*y + 0.953127*z
+}
+
+// linearized converts a sRGB component to linear light, from 0 to 100
+func linearized(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.040449936 {
+		return v / 12.92 * 100
+	}
+	return math.Pow((v+0.055)/1.055, 2.4) * 100
+}
+
+// delinearized converts linear light from 0 to 100 to a sRGB component
+func delinearized(v float64) uint8 {
+	v /= 100
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(Clamp(math.Round(v*255), 0, 255))
+}
+
+// lstarFromColor returns the L* (tone) of a color
+func lstarFromColor(c color.NRGBA) float64 {
+	y := 0.2126*linearized(c.R) + 0.7152*linearized(c.G) + 0.0722*linearized(c.B)
+	return lstarFromY(y)
+}
+
+func lstarFromY(y float64) float64 {
+	const e, kappa = 216.0 / 24389, 24389.0 / 27
+	t := y / 100
+	if t > e {
+		return 116*math.Cbrt(t) - 16
+	}
+	return kappa * t
+}
+
+func yFromLstar(l float64) float64 {
+	const e, kappa = 216.0 / 24389, 24389.0 / 27
+	ft := (l + 16) / 116
+	if ft*ft*ft > e {
+		return 100 * ft * ft * ft
+	}
+	return 100 * (116*ft - 16) / kappa
+}
+
+func grayFromLstar(l float64) color.NRGBA {
+	v := delinearized(yFromLstar(l))
+	return color.NRGBA{R: v, G: v, B: v, A: 255}
+}
+
+func sign(v float64) float64 {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// near returns true when the colors differ by at most d in each channel
+func near(a, b color.NRGBA, d int) bool {
+	return Abs(int(a.R)-int(b.R)) <= d && Abs(int(a.G)-int(b.G)) <= d &&
+		Abs(int(a.B)-int(b.B)) <= d && a.A == b.A
+}
+
+func hueDiff(a, b float64) float64 {
+	return 180 - math.Abs(math.Abs(a-b)-180)
+}
+
+func TestHctFromColor(t *testing.T) {
+	// The values are from the CAM16 tests of the Material color utilities
+	tests := []struct {
+		c                 uint32
+		hue, chroma, tone float64
+	}{
+		{0xFF0000, 27.408, 113.357, 53.241},
+		{0x00FF00, 142.139, 108.410, 87.737},
+		{0x0000FF, 282.788, 87.230, 32.302},
+		{0xFFFFFF, 209.492, 2.869, 100},
+		{0x4285F4, 265.979, 62.269, 56.550},
+	}
+	for _, tt := range tests {
+		h := HctFromColor(RGB(tt.c))
+		if hueDiff(h.Hue, tt.hue) > 0.01 || math.Abs(h.Chroma-tt.chroma) > 0.01 || math.Abs(h.Tone-tt.tone) > 0.01 {
+			t.Errorf("%06X: got %+v, want hue %g, chroma %g, tone %g", tt.c, h, tt.hue, tt.chroma, tt.tone)
+		}
+	}
+	if h := HctFromColor(RGB(0)); h.Chroma != 0 || h.Tone != 0 {
+		t.Errorf("black: got %+v", h)
+	}
+}
+
+func TestHctColor(t *testing.T) {
+	// Colors in the sRGB gamut are returned unchanged
+	for _, c := range []uint32{0x000000, 0xFFFFFF, 0xFF0000, 0x00FF00, 0x0000FF, 0x4285F4, 0x6750A4, 0x808080, 0x123456} {
+		if got := HctFromColor(RGB(c)).Color(); got != RGB(c) {
+			t.Errorf("%06X: round trip gives %s", c, Hex(got))
+		}
+	}
+	// Tonal palettes, from the scheme tests of the Material color utilities
+	tests := []struct {
+		seed uint32
+		tone float64
+		want uint32
+	}{
+		{0x0000FF, 40, 0x343DFF},
+		{0x0000FF, 80, 0xBEC2FF},
+		{0x6750A4, 40, 0x6750A4},
+		{0x6750A4, 80, 0xD0BCFF},
+		{0x6750A4, 90, 0xEADDFF},
+		{0x6750A4, 10, 0x21005D},
+	}
+	for _, tt := range tests {
+		h := HctFromColor(RGB(tt.seed))
+		p := TonalPalette{Hue: h.Hue, Chroma: math.Max(48, h.Chroma)}
+		if got := p.Tone(tt.tone); !near(got, RGB(tt.want), 1) {
+			t.Errorf("%06X T%g: got %s, want #%06X", tt.seed, tt.tone, Hex(got), tt.want)
+		}
+	}
+	// A chroma that can not be shown keeps the hue and tone
+	h := Hct{Hue: 120, Chroma: 200, Tone: 20}.Color()
+	got := HctFromColor(h)
+	if hueDiff(got.Hue, 120) > 2 || math.Abs(got.Tone-20) > 0.5 || got.Chroma > 60 {
+		t.Errorf("out of gamut: got %+v", got)
+	}
+}
+
+func TestTone(t *testing.T) {
+	c := RGB(0x6750A4)
+	if got := Tone(c, 100); got != RGB(0xFFFFFF) {
+		t.Errorf("T100 = %s, want white", Hex(got))
+	}
+	if got := Tone(c, 0); got != RGB(0x000000) {
+		t.Errorf("T0 = %s, want black", Hex(got))
+	}
+	// Tones are ordered by lightness
+	prev := -1.0
+	for tone := 0; tone <= 100; tone += 10 {
+		l := HctFromColor(Tone(c, tone)).Tone
+		if l <= prev || math.Abs(l-float64(tone)) > 0.5 {
+			t.Errorf("T%d has tone %g", tone, l)
+		}
+		prev = l
+	}
+}
+
+func TestPalletFromSeed(t *testing.T) {
+	for _, seed := range []uint32{0x6750A4, 0x4285F4, 0xB33B15, 0x0000FF, 0x808080} {
+		p := PalletFromSeed(RGB(seed))
+		s := HctFromColor(RGB(seed))
+		tests := []struct {
+			name   string
+			c      color.NRGBA
+			hue    float64
+			chroma float64
+		}{
+			{"primary", p.PrimaryColor, s.Hue, math.Max(48, s.Chroma)},
+			{"secondary", p.SecondaryColor, s.Hue, 16},
+			{"tertiary", p.TertiaryColor, s.Hue + 60, 24},
+			{"error", p.ErrorColor, 25, 84},
+			{"neutral", p.NeutralColor, s.Hue, 4},
+			{"neutralVariant", p.NeutralVariantColor, s.Hue, 8},
+		}
+		for _, tt := range tests {
+			h := HctFromColor(tt.c)
+			// All key colors have tone 50. A high chroma outside the gamut is reduced,
+			// and low chromas have imprecise hues after rounding to 8 bit colors.
+			if math.Abs(h.Tone-50) > 0.5 || h.Chroma > tt.chroma+1 || tt.chroma < 48 && math.Abs(h.Chroma-tt.chroma) > 1 {
+				t.Errorf("%06X %s: got %+v, want chroma %g", seed, tt.name, h, tt.chroma)
+			}
+			if h.Chroma > 12 && hueDiff(h.Hue, math.Mod(tt.hue, 360)) > 3 {
+				t.Errorf("%06X %s: got hue %g, want %g", seed, tt.name, h.Hue, tt.hue)
+			}
+		}
+	}
+	// The pallet of the Material baseline seed
+	p := PalletFromSeed(RGB(0x6750A4))
+	th := &Theme{Pallet: p}
+	if got := th.Bg(Primary); !near(got, RGB(0x6750A4), 1) {
+		t.Errorf("primary = %s, want #6750A4", Hex(got))
+	}
+}
@@ -34,21 +34,27 @@ type ImageDef struct {
 }
 
 func ImageFromJpgFile(filename string, fit Fit) func(gtx C) D {
+	w, err := ImageFromFile(filename, fit)
+	if err != nil {
+		panic(err.Error())
+	}
+	return w
+}
+
+// ImageFromFile returns a widget showing the image in the file. The formats
+// that can be read are the ones registered with the image package, so import
+// image/png etc. in the application to read other formats than jpeg.
+func ImageFromFile(filename string, fit Fit) (func(gtx C) D, error) {
 	f, err := os.Open(filename)
-	defer func() {
-		err := f.Close()
-		if err != nil {
-			panic("Jpg file close failed")
-		}
-	}()
 	if err != nil {
-		panic(fmt.Sprintf("Image '%s' not found", filename))
+		return nil, fmt.Errorf("image '%s' not found", filename)
 	}
+	defer f.Close()
 	pict, _, err := image.Decode(f)
 	if err != nil {
-		panic(fmt.Sprintf("Image '%s' has unknown format", filename))
+		return nil, fmt.Errorf("image '%s' has unknown format", filename)
 	}
-	return Image(pict, fit)
+	return Image(pict, fit), nil
 }
 
 func Image(img image.Image, fit Fit) func(gtx C) D {
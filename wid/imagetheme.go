@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+// fallbackSeed is used when an image has no colorful pixels
+var fallbackSeed = RGB(0x4285F4)
+
+// Limits used when scoring seed colors, from the Material color utilities
+const (
+	maxImageSamples   = 256 * 256
+	minSeedChroma     = 5.0
+	minHueProportion  = 0.01
+	targetSeedChroma  = 48.0
+	proportionWeight  = 0.7
+	seedHueNeighbours = 14
+)
+
+// ThemeFromImage returns a theme with colors taken from an image, like a product photo
+// or a wallpaper. This is the Material 3 dynamic color. The image is quantized, the
+// colors are scored by how common and how colorful they are, and the pallet is derived
+// from the best one with PalletFromSeed().
+func ThemeFromImage(img image.Image, fontCollection []text.FontFace, fontSize unit.Sp) *Theme {
+	return NewThemeFromSeed(fontCollection, fontSize, SeedColors(img, 1)[0])
+}
+
+// SeedColors returns up to n colors from the image that are suitable as seed colors,
+// best first. The colors have hues that differ as much as possible. When the image has
+// no suitable colors, a default blue is returned. Nil is returned when n is not positive.
+func SeedColors(img image.Image, n int) []color.NRGBA {
+	if n <= 0 {
+		return nil
+	}
+	type scored struct {
+		hct   Hct
+		c     color.NRGBA
+		score float64
+	}
+	population := quantize(img)
+	total := 0
+	for _, p := range population {
+		total += p.n
+	}
+	// The proportion of the image with each hue, including neighbouring hues
+	var hueProportion [360]float64
+	colors := make([]scored, 0, len(population))
+	for _, p := range population {
+		h := HctFromColor(p.c)
+		colors = append(colors, scored{hct: h, c: p.c})
+		proportion := float64(p.n) / float64(total)
+		hue := int(math.Floor(h.Hue)) % 360
+		for i := hue - seedHueNeighbours; i <= hue+seedHueNeighbours; i++ {
+			hueProportion[(i+360)%360] += proportion
+		}
+	}
+	var candidates []scored
+	for _, s := range colors {
+		proportion := hueProportion[int(math.Round(s.hct.Hue))%360]
+		if s.hct.Chroma < minSeedChroma || proportion <= minHueProportion {
+			continue
+		}
+		chromaWeight := 0.1
+		if s.hct.Chroma >= targetSeedChroma {
+			chromaWeight = 0.3
+		}
+		s.score = proportion*100*proportionWeight + (s.hct.Chroma-targetSeedChroma)*chromaWeight
+		candidates = append(candidates, s)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	// Pick colors with hues far apart, accepting closer hues until there are enough
+	var chosen []color.NRGBA
+	for diff := 90.0; diff >= 15 && len(chosen) < n; diff-- {
+		chosen = chosen[:0]
+		var hues []float64
+		for _, s := range candidates {
+			distinct := true
+			for _, h := range hues {
+				if 180-math.Abs(math.Abs(s.hct.Hue-h)-180) < diff {
+					distinct = false
+					break
+				}
+			}
+			if distinct {
+				hues = append(hues, s.hct.Hue)
+				chosen = append(chosen, s.c)
+				if len(chosen) >= n {
+					break
+				}
+			}
+		}
+	}
+	if len(chosen) == 0 {
+		chosen = append(chosen, fallbackSeed)
+	}
+	return chosen
+}
+
+// colorCount is a color and the number of pixels with it
+type colorCount struct {
+	c color.NRGBA
+	n int
+}
+
+// quantize groups the colors of the image into boxes of 16x16x16 RGB values, and returns
+// the average color and the number of pixels in each box. Large images are sampled, and
+// transparent pixels are skipped.
+func quantize(img image.Image) []colorCount {
+	b := img.Bounds()
+	step := 1
+	for b.Dx()/step*b.Dy()/step > maxImageSamples {
+		step++
+	}
+	type box struct {
+		r, g, b, n int
+	}
+	boxes := map[int]*box{}
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 255 {
+				continue
+			}
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			bx := boxes[key]
+			if bx == nil {
+				bx = &box{}
+				boxes[key] = bx
+			}
+			bx.r += int(c.R)
+			bx.g += int(c.G)
+			bx.b += int(c.B)
+			bx.n++
+		}
+	}
+	counts := make([]colorCount, 0, len(boxes))
+	for _, bx := range boxes {
+		counts = append(counts, colorCount{
+			c: color.NRGBA{R: uint8(bx.r / bx.n), G: uint8(bx.g / bx.n), B: uint8(bx.b / bx.n), A: 255},
+			n: bx.n,
+		})
+	}
+	// Map iteration order is random, so sort to get the same seeds every time
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].n != counts[j].n {
+			return counts[i].n > counts[j].n
+		}
+		return Hex(counts[i].c) < Hex(counts[j].c)
+	})
+	return counts
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// testImage returns an image filled with the colors, each covering the given number of rows
+func testImage(rows map[color.NRGBA]int) image.Image {
+	n := 0
+	for _, r := range rows {
+		n += r
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 100, n))
+	y := 0
+	// The map order does not matter, as SeedColors only counts pixels
+	for c, r := range rows {
+		draw.Draw(img, image.Rect(0, y, 100, y+r), image.NewUniform(c), image.Point{}, draw.Src)
+		y += r
+	}
+	return img
+}
+
+func TestSeedColors(t *testing.T) {
+	blue, orange, green := RGB(0x1565C0), RGB(0xF57C00), RGB(0x2E7D32)
+	tests := []struct {
+		name string
+		img  image.Image
+		n    int
+		want []color.NRGBA
+	}{
+		{"empty", image.NewNRGBA(image.Rectangle{}), 1, []color.NRGBA{fallbackSeed}},
+		{"no colors wanted", testImage(map[color.NRGBA]int{blue: 50}), 0, nil},
+		{"negative count", image.NewNRGBA(image.Rectangle{}), -1, nil},
+		{"transparent", testImage(map[color.NRGBA]int{{R: 255, A: 100}: 50}), 1, []color.NRGBA{fallbackSeed}},
+		{"grayscale", image.NewGray(image.Rect(0, 0, 50, 50)), 3, []color.NRGBA{fallbackSeed}},
+		{"gray levels", testImage(map[color.NRGBA]int{RGB(0x202020): 30, RGB(0x808080): 30, RGB(0xF0F0F0): 30}), 1,
+			[]color.NRGBA{fallbackSeed}},
+		{"one color", testImage(map[color.NRGBA]int{blue: 50}), 3, []color.NRGBA{blue}},
+		{"most common first", testImage(map[color.NRGBA]int{blue: 70, orange: 30}), 2, []color.NRGBA{blue, orange}},
+		{"colorful over gray", testImage(map[color.NRGBA]int{RGB(0x808080): 90, orange: 10}), 1, []color.NRGBA{orange}},
+		{"limited to n", testImage(map[color.NRGBA]int{blue: 50, orange: 30, green: 20}), 2, []color.NRGBA{blue, orange}},
+		// Similar hues count together, and the more colorful one is chosen
+		{"similar hues", testImage(map[color.NRGBA]int{blue: 60, RGB(0x1E88E5): 40}), 2, []color.NRGBA{RGB(0x1E88E5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SeedColors(tt.img, tt.n)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d colors %v, want %v", len(got), got, tt.want)
+			}
+			for i := range got {
+				// The colors are averages of the quantized boxes
+				if !near(got[i], tt.want[i], 2) {
+					t.Errorf("color %d: got %s, want %s", i, Hex(got[i]), Hex(tt.want[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestSeedColorsLargeImage(t *testing.T) {
+	// Large images are sampled, and must give the same result
+	img := image.NewNRGBA(image.Rect(0, 0, 2000, 1500))
+	draw.Draw(img, img.Bounds(), image.NewUniform(RGB(0x6750A4)), image.Point{}, draw.Src)
+	got := SeedColors(img, 1)
+	if len(got) != 1 || got[0] != RGB(0x6750A4) {
+		t.Errorf("got %v", got)
+	}
+}
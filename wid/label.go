@@ -11,7 +11,7 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"image"
-	"strconv"
+	"time"
 )
 
 // LabelDef is the setup for a label.
@@ -26,6 +26,7 @@ type LabelDef struct {
 	TextSize unit.Sp
 	Stringer func(dp int) string
 	dp       int
+	format   valueFormat
 }
 
 // LabelOption is options specific to Edits.
@@ -91,8 +92,21 @@ func (l LabelDef) Layout(gtx C) D {
 
 // Value returns a widget for a value given by stringer function
 func StringerValue(th *Theme, s func(dp int) string, options ...Option) func(gtx C) D {
-	w := LabelDef{
-		Stringer:  s,
+	w := newLabel(th, options...)
+	w.Stringer = s
+	return w.layout
+}
+
+// StringerLabel returns a label showing s.String(). A pointer to a value with
+// a String method gives a live display of the value.
+func StringerLabel(th *Theme, s fmt.Stringer, options ...Option) func(gtx C) D {
+	w := newLabel(th, options...)
+	w.Stringer = func(dp int) string { return w.format.value(s, dp) }
+	return w.layout
+}
+
+func newLabel(th *Theme, options ...Option) *LabelDef {
+	w := &LabelDef{
 		TextSize:  th.TextSize,
 		Alignment: text.Start,
 		Font:      text.Font{Weight: text.Medium, Style: text.Regular},
@@ -106,80 +120,58 @@ func StringerValue(th *Theme, s func(dp int) string, options ...Option) func(gtx
 	w.role = Canvas
 	w.FontSize = 1.0
 	for _, option := range options {
-		option.apply(&w)
+		option.apply(w)
 	}
 	w.padding.Bottom += th.InsidePadding.Bottom
 	w.padding.Top += th.InsidePadding.Top
 	w.padding.Left += th.InsidePadding.Left
 	w.padding.Right += th.InsidePadding.Right
+	return w
+}
 
-	return func(gtx C) D {
-		macro := op.Record(gtx.Ops)
-		dim := w.padding.Layout(gtx, func(gtx C) D {
-			return w.Layout(gtx)
-		})
-		call := macro.Stop()
-		defer clip.Rect(image.Rectangle{Max: dim.Size}).Push(gtx.Ops).Pop()
-		if w.bgColor != nil {
-			paint.Fill(gtx.Ops, w.Bg())
-		}
-		call.Add(gtx.Ops)
-		return dim
-	}
+func (w *LabelDef) layout(gtx C) D {
+	macro := op.Record(gtx.Ops)
+	dim := w.padding.Layout(gtx, func(gtx C) D {
+		return w.Layout(gtx)
+	})
+	call := macro.Stop()
+	defer clip.Rect(image.Rectangle{Max: dim.Size}).Push(gtx.Ops).Pop()
+	if w.bgColor != nil {
+		paint.Fill(gtx.Ops, w.Bg())
+	}
+	call.Add(gtx.Ops)
+	return dim
 }
 
+// Value is the types that can be shown by Label. Pointers are read each frame, with GuiLock held.
+// Go does not allow interfaces with methods in a type set, so use StringerLabel for fmt.Stringer.
 type Value interface {
-	int | float64 | float32 | string | *int | *float64 | *float32 | *string
+	int | float64 | float32 | string | bool | time.Duration | time.Time |
+		*int | *float64 | *float32 | *string | *bool | *time.Duration | *time.Time
 }
 
-// Label returns a widget for a label showing a string
+// Label returns a widget for a label showing a string or a value.
+// Values are formatted according to the options Dp(), Thousands(), SI(), Eng(),
+// Unit(), Percent(), FixedWidth(), TimeLayout() and BoolText().
 func Label[V Value](th *Theme, v V, options ...Option) func(gtx C) D {
-	if x, ok := any(v).(int); ok {
-		s := func(dp int) string { return fmt.Sprintf("%d", x) }
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(*int); ok {
-		s := func(dp int) string {
-			GuiLock.RLock()
-			defer GuiLock.RUnlock()
-			return fmt.Sprintf("%d", *x)
-		}
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(float64); ok {
-		s := func(dp int) string { return strconv.FormatFloat(float64(x), 'f', dp, 64) }
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(*float64); ok {
-		s := func(dp int) string {
-			GuiLock.RLock()
-			defer GuiLock.RUnlock()
-			return strconv.FormatFloat(float64(*x), 'f', dp, 64)
-		}
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(float32); ok {
-		s := func(dp int) string { return strconv.FormatFloat(float64(x), 'f', dp, 32) }
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(*float32); ok {
-		s := func(dp int) string {
-			GuiLock.RLock()
-			defer GuiLock.RUnlock()
-			return strconv.FormatFloat(float64(*x), 'f', dp, 32)
-		}
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(string); ok {
-		s := func(dp int) string { return x }
-		return StringerValue(th, s, options...)
-	}
-	if x, ok := any(v).(*string); ok {
-		GuiLock.RLock()
-		defer GuiLock.RUnlock()
-		s := func(dp int) string { return *x }
-		return StringerValue(th, s, options...)
-	}
-	s := func(dp int) string { return fmt.Sprintf("%v", v) }
-	return StringerValue(th, s, options...)
+	w := newLabel(th, options...)
+	get := func() any { return v }
+	switch x := any(v).(type) {
+	case *int:
+		get = func() any { return *x }
+	case *float64:
+		get = func() any { return *x }
+	case *float32:
+		get = func() any { return *x }
+	case *string:
+		get = func() any { return *x }
+	case *bool:
+		get = func() any { return *x }
+	case *time.Duration:
+		get = func() any { return *x }
+	case *time.Time:
+		get = func() any { return *x }
+	}
+	w.Stringer = func(dp int) string { return w.format.value(get(), dp) }
+	return w.layout
 }
@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"unicode"
+)
+
+// TokenKind is the syntactic class of a token, used to select its color
+type TokenKind uint8
+
+const (
+	// TokText is plain text that is not highlighted
+	TokText TokenKind = iota
+	// TokKeyword is a reserved word, or a section header in ini files
+	TokKeyword
+	// TokType is a predeclared type or constant
+	TokType
+	// TokString is a quoted string or character literal
+	TokString
+	// TokNumber is a numeric literal, true/false or null
+	TokNumber
+	// TokComment is a line or block comment
+	TokComment
+	// TokKey is a key in a key/value pair (json or ini)
+	TokKey
+	// TokPunct is an operator, bracket or separator
+	TokPunct
+)
+
+// Token is a highlighted range of the source. Start and End are rune offsets.
+type Token struct {
+	Kind       TokenKind
+	Start, End int
+}
+
+// Lexer splits a source text into tokens for syntax highlighting.
+// Tokens must be sorted and must not overlap. Text not covered by a token
+// is drawn as TokText.
+type Lexer interface {
+	Tokens(src []rune) []Token
+}
+
+// LexerFunc is an adapter to allow the use of ordinary functions as lexers.
+type LexerFunc func(src []rune) []Token
+
+// Tokens calls f(src)
+func (f LexerFunc) Tokens(src []rune) []Token {
+	return f(src)
+}
+
+var goKeywords = map[string]TokenKind{
+	"break": TokKeyword, "case": TokKeyword, "chan": TokKeyword, "const": TokKeyword,
+	"continue": TokKeyword, "default": TokKeyword, "defer": TokKeyword, "else": TokKeyword,
+	"fallthrough": TokKeyword, "for": TokKeyword, "func": TokKeyword, "go": TokKeyword,
+	"goto": TokKeyword, "if": TokKeyword, "import": TokKeyword, "interface": TokKeyword,
+	"map": TokKeyword, "package": TokKeyword, "range": TokKeyword, "return": TokKeyword,
+	"select": TokKeyword, "struct": TokKeyword, "switch": TokKeyword, "type": TokKeyword,
+	"var":  TokKeyword,
+	"bool": TokType, "byte": TokType, "complex64": TokType, "complex128": TokType,
+	"error": TokType, "float32": TokType, "float64": TokType, "int": TokType,
+	"int8": TokType, "int16": TokType, "int32": TokType, "int64": TokType,
+	"rune": TokType, "string": TokType, "uint": TokType, "uint8": TokType,
+	"uint16": TokType, "uint32": TokType, "uint64": TokType, "uintptr": TokType,
+	"any": TokType, "true": TokNumber, "false": TokNumber, "nil": TokNumber, "iota": TokNumber,
+}
+
+// GoLexer highlights Go source code
+var GoLexer Lexer = LexerFunc(lexGo)
+
+// JSONLexer highlights json documents
+var JSONLexer Lexer = LexerFunc(lexJSON)
+
+// IniLexer highlights ini/conf files with [sections], key=value pairs and ; or # comments
+var IniLexer Lexer = LexerFunc(lexIni)
+
+func isIdent(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanWhile returns the first index from i where f is false
+func scanWhile(src []rune, i int, f func(r rune) bool) int {
+	for i < len(src) && f(src[i]) {
+		i++
+	}
+	return i
+}
+
+// scanQuoted returns the index after the closing quote q, starting at the
+// opening quote. Backslash escapes are skipped unless raw is set.
+// Unterminated strings end at the end of the line.
+func scanQuoted(src []rune, i int, q rune, raw bool) int {
+	for i++; i < len(src); i++ {
+		switch {
+		case src[i] == q:
+			return i + 1
+		case src[i] == '\\' && !raw:
+			i++
+		case src[i] == '\n' && !raw:
+			return i
+		}
+	}
+	return len(src)
+}
+
+func scanNumber(src []rune, i int) int {
+	return scanWhile(src, i, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '.' || r == '_'
+	})
+}
+
+func lexGo(src []rune) []Token {
+	var tokens []Token
+	for i := 0; i < len(src); {
+		r := src[i]
+		start := i
+		switch {
+		case r == '/' && i+1 < len(src) && src[i+1] == '/':
+			i = scanWhile(src, i, func(r rune) bool { return r != '\n' })
+			tokens = append(tokens, Token{TokComment, start, i})
+		case r == '/' && i+1 < len(src) && src[i+1] == '*':
+			i += 3
+			for i < len(src) && !(src[i-1] == '*' && src[i] == '/') {
+				i++
+			}
+			i = Min(i+1, len(src))
+			tokens = append(tokens, Token{TokComment, start, i})
+		case r == '"' || r == '\'':
+			i = scanQuoted(src, i, r, false)
+			tokens = append(tokens, Token{TokString, start, i})
+		case r == '`':
+			i = scanQuoted(src, i, r, true)
+			tokens = append(tokens, Token{TokString, start, i})
+		case unicode.IsDigit(r):
+			i = scanNumber(src, i)
+			tokens = append(tokens, Token{TokNumber, start, i})
+		case isIdent(r):
+			i = scanWhile(src, i, isIdent)
+			if k, ok := goKeywords[string(src[start:i])]; ok {
+				tokens = append(tokens, Token{k, start, i})
+			}
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			i++
+			tokens = append(tokens, Token{TokPunct, start, i})
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+func lexJSON(src []rune) []Token {
+	var tokens []Token
+	for i := 0; i < len(src); {
+		r := src[i]
+		start := i
+		switch {
+		case r == '"':
+			i = scanQuoted(src, i, r, false)
+			// A string followed by a colon is a key
+			j := scanWhile(src, i, unicode.IsSpace)
+			if j < len(src) && src[j] == ':' {
+				tokens = append(tokens, Token{TokKey, start, i})
+			} else {
+				tokens = append(tokens, Token{TokString, start, i})
+			}
+		case r == '-' || unicode.IsDigit(r):
+			i = scanWhile(src, i+1, func(r rune) bool {
+				return unicode.IsDigit(r) || r == '.' || r == 'e' || r == 'E' || r == '+' || r == '-'
+			})
+			tokens = append(tokens, Token{TokNumber, start, i})
+		case unicode.IsLetter(r):
+			i = scanWhile(src, i, unicode.IsLetter)
+			tokens = append(tokens, Token{TokNumber, start, i})
+		case r == '{' || r == '}' || r == '[' || r == ']' || r == ':' || r == ',':
+			i++
+			tokens = append(tokens, Token{TokPunct, start, i})
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+func lexIni(src []rune) []Token {
+	var tokens []Token
+	notEol := func(r rune) bool { return r != '\n' }
+	for i := 0; i < len(src); {
+		// Each iteration handles one line
+		i = scanWhile(src, i, func(r rune) bool { return r == ' ' || r == '\t' })
+		if i >= len(src) {
+			break
+		}
+		start := i
+		switch src[i] {
+		case '\n':
+		case ';', '#':
+			i = scanWhile(src, i, notEol)
+			tokens = append(tokens, Token{TokComment, start, i})
+		case '[':
+			i = scanWhile(src, i, notEol)
+			tokens = append(tokens, Token{TokKeyword, start, i})
+		default:
+			i = scanWhile(src, i, func(r rune) bool { return r != '=' && r != ':' && r != '\n' })
+			if i < len(src) && src[i] != '\n' {
+				tokens = append(tokens, Token{TokKey, start, i})
+				tokens = append(tokens, Token{TokPunct, i, i + 1})
+				i = scanWhile(src, i+1, func(r rune) bool { return r == ' ' || r == '\t' })
+				start = i
+				i = scanWhile(src, i, notEol)
+				if start < i {
+					kind := TokString
+					if unicode.IsDigit(src[start]) || src[start] == '-' {
+						kind = TokNumber
+					}
+					tokens = append(tokens, Token{kind, start, i})
+				}
+			}
+		}
+		i = scanWhile(src, i, notEol) + 1
+	}
+	return tokens
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"reflect"
+	"testing"
+)
+
+// tok is a token given by its text, for readable test tables
+type tok struct {
+	kind TokenKind
+	text string
+}
+
+func tokenTexts(src []rune, tokens []Token) []tok {
+	var list []tok
+	for _, t := range tokens {
+		list = append(list, tok{t.Kind, string(src[t.Start:t.End])})
+	}
+	return list
+}
+
+func TestLexers(t *testing.T) {
+	tests := []struct {
+		name  string
+		lexer func(src []rune) []Token
+		src   string
+		want  []tok
+	}{
+		{"go empty", lexGo, "", nil},
+		{"go keywords", lexGo, "func f() int", []tok{
+			{TokKeyword, "func"}, {TokPunct, "("}, {TokPunct, ")"}, {TokType, "int"}}},
+		{"go strings", lexGo, "s := \"a\\\"b\" + `c\nd` + 'e'", []tok{
+			{TokPunct, ":"}, {TokPunct, "="}, {TokString, "\"a\\\"b\""}, {TokPunct, "+"},
+			{TokString, "`c\nd`"}, {TokPunct, "+"}, {TokString, "'e'"}}},
+		{"go numbers", lexGo, "x1 = 0x1F + 1_000.5", []tok{
+			{TokPunct, "="}, {TokNumber, "0x1F"}, {TokPunct, "+"}, {TokNumber, "1_000.5"}}},
+		{"go comments", lexGo, "a // b\n/* c\n*/ nil", []tok{
+			{TokComment, "// b"}, {TokComment, "/* c\n*/"}, {TokNumber, "nil"}}},
+		{"go open comment", lexGo, "/* c", []tok{{TokComment, "/* c"}}},
+		{"go open string", lexGo, "\"abc", []tok{{TokString, "\"abc"}}},
+		{"json", lexJSON, `{"a": "b", "c": [1, -2.5e3, true, null]}`, []tok{
+			{TokPunct, "{"}, {TokKey, `"a"`}, {TokPunct, ":"}, {TokString, `"b"`}, {TokPunct, ","},
+			{TokKey, `"c"`}, {TokPunct, ":"}, {TokPunct, "["}, {TokNumber, "1"}, {TokPunct, ","},
+			{TokNumber, "-2.5e3"}, {TokPunct, ","}, {TokNumber, "true"}, {TokPunct, ","},
+			{TokNumber, "null"}, {TokPunct, "]"}, {TokPunct, "}"}}},
+		{"json key before space", lexJSON, "{\"a\"\n : 1}", []tok{
+			{TokPunct, "{"}, {TokKey, `"a"`}, {TokPunct, ":"}, {TokNumber, "1"}, {TokPunct, "}"}}},
+		{"ini", lexIni, "; c\n[main]\nname=giov\n  size: 12\n# d\n", []tok{
+			{TokComment, "; c"}, {TokKeyword, "[main]"}, {TokKey, "name"}, {TokPunct, "="},
+			{TokString, "giov"}, {TokKey, "size"}, {TokPunct, ":"}, {TokNumber, "12"}, {TokComment, "# d"}}},
+		{"ini without value", lexIni, "a=\nb\n", []tok{{TokKey, "a"}, {TokPunct, "="}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := []rune(tt.src)
+			got := tokenTexts(src, tt.lexer(src))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
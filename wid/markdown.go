@@ -0,0 +1,304 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+// MarkdownDef holds the setup for a markdown document
+type MarkdownDef struct {
+	th        *Theme
+	onLink    func(url string)
+	linkColor color.NRGBA
+	codeBg    color.NRGBA
+	blocks    []layout.Widget
+}
+
+// MarkdownOption is options specific to the markdown viewer
+type MarkdownOption func(*MarkdownDef)
+
+func (o MarkdownOption) apply(cfg interface{}) {
+	if m, ok := cfg.(*MarkdownDef); ok {
+		o(m)
+	}
+}
+
+// OnLink sets the function called with the url when a link is clicked
+func OnLink(f func(url string)) MarkdownOption {
+	return func(m *MarkdownDef) {
+		m.onLink = f
+	}
+}
+
+// Markdown returns a scrollable list showing the markdown text src.
+// Headings, paragraphs, emphasis, inline code, fenced code blocks, lists,
+// links, images and horizontal rules are supported. Images are read with ImageFromFile(),
+// so applications showing png images must import image/png.
+func Markdown(th *Theme, src string, options ...Option) layout.Widget {
+	return List(th, Overlay, MarkdownBlocks(th, src, options...)...)
+}
+
+// MarkdownBlocks returns the markdown text as a list of widgets, one for each block,
+// so it can be combined with other widgets in a List.
+func MarkdownBlocks(th *Theme, src string, options ...Option) []layout.Widget {
+	m := &MarkdownDef{th: th}
+	for _, option := range options {
+		option.apply(m)
+	}
+	m.parse(strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n"))
+	for i, w := range m.blocks {
+		m.blocks[i] = m.colored(w)
+	}
+	return m.blocks
+}
+
+// colored sets the link and code colors from the theme before drawing a block,
+// so that they follow DarkMode and the color transitions
+func (m *MarkdownDef) colored(w layout.Widget) layout.Widget {
+	return func(gtx C) D {
+		m.linkColor = m.th.Bg(Primary)
+		m.codeBg = m.th.Bg(SurfaceVariant)
+		return w(gtx)
+	}
+}
+
+// listMarker returns the marker and the rest of the line if the line is a list item
+func listMarker(line string) (marker string, rest string, ok bool) {
+	if len(line) > 1 && strings.ContainsRune("-*+", rune(line[0])) && line[1] == ' ' {
+		return "•", line[2:], true
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && i+1 < len(line) && (line[i] == '.' || line[i] == ')') && line[i+1] == ' ' {
+		return line[:i+1], line[i+2:], true
+	}
+	return "", "", false
+}
+
+func isRule(line string) bool {
+	s := strings.ReplaceAll(line, " ", "")
+	return len(s) >= 3 && (strings.Trim(s, "-") == "" || strings.Trim(s, "*") == "" || strings.Trim(s, "_") == "")
+}
+
+func (m *MarkdownDef) parse(lines []string) {
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			m.paragraph(para)
+			para = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, strings.ReplaceAll(lines[i], "\t", "    "))
+			}
+			m.codeBlock(strings.Join(code, "\n"))
+		case headingLevel(trimmed) > 0:
+			flush()
+			level := headingLevel(trimmed)
+			m.heading(level, strings.TrimSpace(strings.TrimRight(trimmed[level:], "#")))
+		case isRule(trimmed) && len(para) == 0:
+			m.blocks = append(m.blocks, Separator(m.th, unit.Dp(1), Pads(4)))
+		default:
+			if marker, rest, ok := listMarker(trimmed); ok {
+				flush()
+				m.listItem(marker, indent/2, rest)
+				continue
+			}
+			// Two trailing spaces is a hard line break
+			if strings.HasSuffix(lines[i], "  ") {
+				trimmed += "\n"
+			}
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+}
+
+// headingLevel returns the number of # at the start of a heading line, or 0
+// when the line is not a heading. As in CommonMark, the # must be followed by a space.
+func headingLevel(s string) int {
+	level := len(s) - len(strings.TrimLeft(s, "#"))
+	if level == 0 || level > 6 || (level < len(s) && s[level] != ' ' && s[level] != '\t') {
+		return 0
+	}
+	return level
+}
+
+func (m *MarkdownDef) heading(level int, s string) {
+	var size Option = Bold()
+	switch level {
+	case 1:
+		size = Heading()
+	case 2:
+		size = Large()
+	}
+	m.blocks = append(m.blocks, RichLabel(m.th, m.inline(s), size, Bold()))
+}
+
+func (m *MarkdownDef) paragraph(lines []string) {
+	s := strings.Join(lines, " ")
+	s = strings.ReplaceAll(s, "\n ", "\n")
+	// A paragraph with only an image is shown as an image block
+	if strings.HasPrefix(s, "![") && strings.HasSuffix(s, ")") {
+		if alt, src, n := parseLink(s[1:]); n == len(s)-1 {
+			m.blocks = append(m.blocks, m.image(alt, src))
+			return
+		}
+	}
+	m.blocks = append(m.blocks, RichLabel(m.th, m.inline(s)))
+}
+
+func (m *MarkdownDef) codeBlock(s string) {
+	m.blocks = append(m.blocks, RichLabel(m.th, []Span{{Text: s, Mono: true}}, Bg(&m.codeBg)))
+}
+
+func (m *MarkdownDef) listItem(marker string, level int, s string) {
+	th := m.th
+	mark := RichLabel(th, []Span{{Text: marker}}, Right())
+	content := RichLabel(th, m.inline(s))
+	m.blocks = append(m.blocks, func(gtx C) D {
+		indent := gtx.Sp(th.TextSize * 2 * unit.Sp(level+1))
+		c := gtx
+		c.Constraints.Min.X = indent
+		c.Constraints.Max.X = indent
+		d1 := mark(c)
+		c.Constraints.Min.X = 0
+		c.Constraints.Max.X = Max(0, gtx.Constraints.Max.X-indent)
+		defer op.Offset(image.Pt(indent, 0)).Push(gtx.Ops).Pop()
+		d2 := content(c)
+		return D{Size: image.Pt(gtx.Constraints.Max.X, Max(d1.Size.Y, d2.Size.Y))}
+	})
+}
+
+func (m *MarkdownDef) image(alt, src string) layout.Widget {
+	if w, err := ImageFromFile(src, ScaleDown); err == nil {
+		return w
+	}
+	return RichLabel(m.th, []Span{{Text: "[" + alt + "]", Style: text.Italic}})
+}
+
+// parseLink parses "[text](url)" at the start of s.
+// It returns the text, the url and the length of the link, or zero length if there is no link.
+func parseLink(s string) (string, string, int) {
+	if !strings.HasPrefix(s, "[") {
+		return "", "", 0
+	}
+	i := strings.Index(s, "](")
+	if i < 0 {
+		return "", "", 0
+	}
+	j := strings.IndexByte(s[i:], ')')
+	if j < 0 {
+		return "", "", 0
+	}
+	return s[1:i], s[i+2 : i+j], i + j + 1
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// emphasis returns true if the * or _ at s[i] starts or ends emphasis.
+// Opening markers must be followed by text, closing markers must follow text,
+// and underscores within words are ignored.
+func emphasis(s string, i int, closing bool) bool {
+	if closing {
+		return i > 0 && s[i-1] != ' ' && (s[i] == '*' || i+1 == len(s) || !isAlnum(s[i+1]))
+	}
+	return i+1 < len(s) && s[i+1] != ' ' && (s[i] == '*' || i == 0 || !isAlnum(s[i-1]))
+}
+
+// inline converts text with emphasis, code and links to spans
+func (m *MarkdownDef) inline(s string) []Span {
+	var spans []Span
+	var cur strings.Builder
+	var bold, italic bool
+	flush := func() {
+		if cur.Len() == 0 {
+			return
+		}
+		sp := Span{Text: cur.String()}
+		if bold {
+			sp.Weight = text.Bold
+		}
+		if italic {
+			sp.Style = text.Italic
+		}
+		spans = append(spans, sp)
+		cur.Reset()
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			cur.WriteByte(s[i+1])
+			i += 2
+		case strings.HasPrefix(s[i:], "**") || strings.HasPrefix(s[i:], "__"):
+			flush()
+			bold = !bold
+			i += 2
+		case (c == '*' || c == '_') && emphasis(s, i, italic):
+			flush()
+			italic = !italic
+			i++
+		case c == '`' && strings.IndexByte(s[i+1:], '`') >= 0:
+			flush()
+			j := strings.IndexByte(s[i+1:], '`')
+			spans = append(spans, Span{Text: s[i+1 : i+1+j], Mono: true})
+			i += j + 2
+		case c == '[' || c == '!' && strings.HasPrefix(s[i+1:], "["):
+			ofs := 0
+			if c == '!' {
+				ofs = 1
+			}
+			txt, url, n := parseLink(s[i+ofs:])
+			if n == 0 {
+				cur.WriteByte(c)
+				i++
+				break
+			}
+			flush()
+			if ofs == 1 {
+				// Inline images are shown as their alt text
+				spans = append(spans, Span{Text: txt, Style: text.Italic})
+			} else {
+				spans = append(spans, m.link(txt, url))
+			}
+			i += ofs + n
+		default:
+			cur.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return spans
+}
+
+func (m *MarkdownDef) link(txt, url string) Span {
+	sp := Span{Text: txt, Color: &m.linkColor, Underline: true}
+	if m.onLink != nil {
+		sp.OnClick = func() { m.onLink(url) }
+	}
+	return sp
+}
@@ -0,0 +1,615 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"strings"
+	"unicode"
+
+	"gioui.org/gesture"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// MenuItem is an entry in a menu bar, a context menu or a submenu.
+type MenuItem struct {
+	// Text is the label. A '&' before a letter marks the mnemonic key,
+	// otherwise the first letter is used.
+	Text string
+	// Icon is shown to the left of the text
+	Icon *Icon
+	// Shortcut is the accelerator text shown to the right, like "Ctrl+S".
+	// It is only displayed, the key must be handled by the application.
+	Shortcut string
+	// Checked makes the item checkable. It is toggled when the item is clicked.
+	Checked  *bool
+	Disabled bool
+	// Do is called when the item is clicked
+	Do func()
+	// Items is the content of a submenu
+	Items     []MenuItem
+	separator bool
+}
+
+// MenuSeparator is a horizontal line between groups of menu items
+var MenuSeparator = MenuItem{separator: true}
+
+var (
+	checkIcon   *Icon
+	submenuIcon *Icon
+)
+
+// menuKeys are the keys handled by an open menu
+const menuKeys = "↑|↓|←|→|⏎|Space|⎋|(Shift)-[A,B,C,D,E,F,G,H,I,J,K,L,M,N,O,P,Q,R,S,T,U,V,W,X,Y,Z,0,1,2,3,4,5,6,7,8,9]"
+
+// mnemonic returns the text without the '&' marker, and the name of the mnemonic key
+func mnemonic(s string) (string, string) {
+	i := strings.IndexByte(s, '&')
+	if i >= 0 && i+1 < len(s) {
+		label := s[:i] + s[i+1:]
+		return label, strings.ToUpper(string([]rune(s[i+1:])[0]))
+	}
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return s, string(unicode.ToUpper(r))
+		}
+	}
+	return s, ""
+}
+
+// menuPopup is an open menu, drawn on top of the form with op.Defer
+type menuPopup struct {
+	th       *Theme
+	items    []MenuItem
+	clicks   []gesture.Click
+	hovered  int
+	parent   *menuPopup
+	sub      *menuPopup
+	subIndex int
+	rows     []image.Rectangle
+	keyTag   struct{}
+	tag      struct{}
+	focus    bool
+	// onClose closes the whole chain of menus
+	onClose func()
+	// onSwitch is set for menus in a menu bar, moving to the next or previous menu
+	onSwitch func(dir int)
+}
+
+func newMenuPopup(th *Theme, items []MenuItem, parent *menuPopup, onClose func()) *menuPopup {
+	return &menuPopup{
+		th:      th,
+		items:   items,
+		clicks:  make([]gesture.Click, len(items)),
+		hovered: -1,
+		parent:  parent,
+		focus:   true,
+		onClose: onClose,
+	}
+}
+
+func (m *menuPopup) enabled(i int) bool {
+	return i >= 0 && i < len(m.items) && !m.items[i].separator && !m.items[i].Disabled
+}
+
+// move the keyboard selection to the next enabled item in direction dir
+func (m *menuPopup) move(dir int) {
+	n := len(m.items)
+	for j := 0; j < n; j++ {
+		m.hovered = (m.hovered + dir + n) % n
+		if m.enabled(m.hovered) {
+			return
+		}
+	}
+	m.hovered = -1
+}
+
+func (m *menuPopup) openSub(i int, focus bool) {
+	if m.sub == nil || m.subIndex != i {
+		m.sub = newMenuPopup(m.th, m.items[i].Items, m, m.onClose)
+		m.subIndex = i
+	}
+	m.sub.focus = focus
+	if focus && m.sub.hovered < 0 {
+		m.sub.move(1)
+	}
+}
+
+// closeSub closes the submenu and gives the focus back to this menu
+func (m *menuPopup) closeSub() {
+	m.sub = nil
+	m.focus = true
+}
+
+func (m *menuPopup) activate(i int, keyboard bool) {
+	if !m.enabled(i) {
+		return
+	}
+	it := m.items[i]
+	if len(it.Items) > 0 {
+		m.openSub(i, keyboard)
+		return
+	}
+	if it.Checked != nil {
+		GuiLock.Lock()
+		*it.Checked = !*it.Checked
+		GuiLock.Unlock()
+	}
+	m.onClose()
+	if it.Do != nil {
+		it.Do()
+	}
+}
+
+func (m *menuPopup) handleEvents(gtx C) {
+	for _, e := range gtx.Events(&m.keyTag) {
+		e, ok := e.(key.Event)
+		if !ok || e.State != key.Press {
+			continue
+		}
+		switch e.Name {
+		case key.NameUpArrow:
+			m.move(-1)
+		case key.NameDownArrow:
+			m.move(1)
+		case key.NameRightArrow:
+			if m.enabled(m.hovered) && len(m.items[m.hovered].Items) > 0 {
+				m.openSub(m.hovered, true)
+			} else if m.onSwitch != nil {
+				m.onSwitch(1)
+			}
+		case key.NameLeftArrow:
+			if m.parent != nil {
+				m.parent.closeSub()
+			} else if m.onSwitch != nil {
+				m.onSwitch(-1)
+			}
+		case key.NameReturn, key.NameSpace:
+			m.activate(m.hovered, true)
+		case key.NameEscape:
+			if m.parent != nil {
+				m.parent.closeSub()
+			} else {
+				m.onClose()
+			}
+		default:
+			for i, it := range m.items {
+				if _, k := mnemonic(it.Text); k == e.Name && m.enabled(i) {
+					m.hovered = i
+					m.activate(i, true)
+					break
+				}
+			}
+		}
+	}
+	for i := range m.clicks {
+		for _, e := range m.clicks[i].Events(gtx) {
+			if e.Type == gesture.TypeClick {
+				m.activate(i, false)
+			}
+		}
+		if m.clicks[i].Hovered() && m.hovered != i && m.enabled(i) {
+			// Hovering an item opens its submenu, and closes any other submenu
+			m.hovered = i
+			if len(m.items[i].Items) > 0 {
+				m.openSub(i, false)
+			} else {
+				m.sub = nil
+			}
+		}
+	}
+}
+
+// layout draws the menu with its top left corner at the origin. abs is the
+// position of the origin in the window, used to keep submenus inside the window.
+func (m *menuPopup) layout(gtx C, abs image.Point) D {
+	m.handleEvents(gtx)
+	th := m.th
+	fg := th.Fg(Canvas)
+	c := gtx
+	c.Constraints.Min = image.Point{}
+	c.Constraints.Max.X = inf
+	pad := gtx.Dp(th.InsidePadding.Top)
+	padX := gtx.Dp(th.InsidePadding.Left) * 2
+	textHeight := gtx.Sp(th.TextSize)
+
+	// Record labels and shortcuts to find the column widths
+	labels := make([]op.CallOp, len(m.items))
+	shortcuts := make([]op.CallOp, len(m.items))
+	shortcutWidths := make([]int, len(m.items))
+	labelWidth, shortcutWidth, arrowWidth := gtx.Sp(th.TextSize*8), 0, 0
+	for i, it := range m.items {
+		if it.separator {
+			continue
+		}
+		col := fg
+		if it.Disabled || gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		txt, _ := mnemonic(it.Text)
+		macro := op.Record(gtx.Ops)
+		paint.ColorOp{Color: col}.Add(gtx.Ops)
+		dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, txt)
+		labels[i] = macro.Stop()
+		labelWidth = Max(labelWidth, dims.Size.X)
+		textHeight = Max(textHeight, dims.Size.Y)
+		if it.Shortcut != "" {
+			macro := op.Record(gtx.Ops)
+			paint.ColorOp{Color: MulAlpha(col, 160)}.Add(gtx.Ops)
+			dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, it.Shortcut)
+			shortcuts[i] = macro.Stop()
+			shortcutWidths[i] = dims.Size.X
+			shortcutWidth = Max(shortcutWidth, dims.Size.X+textHeight*2)
+		}
+		if len(it.Items) > 0 {
+			arrowWidth = textHeight
+		}
+	}
+	iconSize := textHeight
+	iconColumn := iconSize * 3 / 2
+	width := padX + iconColumn + labelWidth + shortcutWidth + arrowWidth + padX
+	rowHeight := textHeight + 2*pad
+	sepHeight := 2*pad + gtx.Dp(1)
+
+	// Find the rows
+	m.rows = m.rows[:0]
+	y := pad
+	for _, it := range m.items {
+		h := rowHeight
+		if it.separator {
+			h = sepHeight
+		}
+		m.rows = append(m.rows, image.Rect(0, y, width, y+h))
+		y += h
+	}
+	size := image.Pt(width, y+pad)
+	rect := image.Rectangle{Max: size}
+	rr := gtx.Dp(th.BorderCornerRadius)
+
+	DrawShadow(gtx, rect, rr, gtx.Dp(th.Elevation))
+	paint.FillShape(gtx.Ops, th.Bg(Canvas), clip.UniformRRect(rect, rr).Op(gtx.Ops))
+	r := clip.Rect(rect).Push(gtx.Ops)
+	// Take all pointer events within the menu, so they do not close it
+	pointer.InputOp{Tag: &m.tag, Types: pointer.Press | pointer.Release | pointer.Move}.Add(gtx.Ops)
+	if gtx.Queue != nil {
+		key.InputOp{Tag: &m.keyTag, Keys: menuKeys}.Add(gtx.Ops)
+		if m.focus {
+			key.FocusOp{Tag: &m.keyTag}.Add(gtx.Ops)
+			m.focus = false
+		}
+	}
+	r.Pop()
+
+	ic := gtx
+	ic.Constraints = layout.Exact(image.Pt(iconSize, iconSize))
+	for i, it := range m.items {
+		row := m.rows[i]
+		if it.separator {
+			line := image.Rect(padX/2, row.Min.Y+pad, width-padX/2, row.Min.Y+pad+gtx.Dp(1))
+			paint.FillShape(gtx.Ops, th.Fg(Outline), clip.Rect(line).Op())
+			continue
+		}
+		col := fg
+		if it.Disabled || gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		if i == m.hovered && m.enabled(i) {
+			paint.FillShape(gtx.Ops, MulAlpha(fg, 24), clip.Rect(row).Op())
+		}
+		cl := clip.Rect(row).Push(gtx.Ops)
+		m.clicks[i].Add(gtx.Ops)
+		cl.Pop()
+		o := op.Offset(image.Pt(padX, row.Min.Y+pad)).Push(gtx.Ops)
+		if it.Checked != nil {
+			GuiLock.RLock()
+			checked := *it.Checked
+			GuiLock.RUnlock()
+			if checked {
+				_ = checkIcon.Layout(ic, col)
+			}
+		} else if it.Icon != nil {
+			_ = it.Icon.Layout(ic, col)
+		}
+		o.Pop()
+		o = op.Offset(image.Pt(padX+iconColumn, row.Min.Y+pad)).Push(gtx.Ops)
+		labels[i].Add(gtx.Ops)
+		o.Pop()
+		if it.Shortcut != "" {
+			o := op.Offset(image.Pt(width-padX-arrowWidth-shortcutWidths[i], row.Min.Y+pad)).Push(gtx.Ops)
+			shortcuts[i].Add(gtx.Ops)
+			o.Pop()
+		}
+		if len(it.Items) > 0 {
+			o := op.Offset(image.Pt(width-padX-arrowWidth, row.Min.Y+pad)).Push(gtx.Ops)
+			_ = submenuIcon.Layout(ic, col)
+			o.Pop()
+		}
+	}
+
+	// Draw the open submenu to the right of its item, or to the left if there is no space
+	if m.sub != nil && m.subIndex < len(m.rows) {
+		macro := op.Record(gtx.Ops)
+		p := image.Pt(width, m.rows[m.subIndex].Min.Y-pad)
+		d := m.sub.layout(gtx, abs.Add(p))
+		call := macro.Stop()
+		if abs.X+p.X+d.Size.X > WinX {
+			p.X = Max(-abs.X, -d.Size.X)
+		}
+		if abs.Y+p.Y+d.Size.Y > WinY {
+			p.Y = Max(-abs.Y, WinY-abs.Y-d.Size.Y)
+		}
+		o := op.Offset(p).Push(gtx.Ops)
+		call.Add(gtx.Ops)
+		o.Pop()
+	}
+	return D{Size: size}
+}
+
+// catchOutside adds an input area covering the whole window, given the window position abs
+// of the current origin. It is used to close menus when the user clicks outside them.
+func catchOutside(gtx C, tag *struct{}, abs image.Point) {
+	r := clip.Rect(image.Rect(-abs.X, -abs.Y, WinX-abs.X, WinY-abs.Y)).Push(gtx.Ops)
+	pointer.InputOp{Tag: tag, Types: pointer.Press | pointer.Move}.Add(gtx.Ops)
+	r.Pop()
+}
+
+// ContextMenuDef wraps a widget with a popup menu opened by a right click
+type ContextMenuDef struct {
+	Base
+	w       layout.Widget
+	items   []MenuItem
+	popup   *menuPopup
+	pos     image.Point
+	abs     image.Point
+	tag     struct{}
+	catcher struct{}
+}
+
+// ContextMenu returns w with a popup menu that is opened by a right click on it.
+func ContextMenu(th *Theme, w layout.Widget, items ...MenuItem) layout.Widget {
+	c := &ContextMenuDef{w: w, items: items}
+	c.th = th
+	return c.Layout
+}
+
+func (c *ContextMenuDef) open(pos image.Point) {
+	c.pos = pos
+	// The mouse position gives the position of the widget in the window
+	c.abs = image.Pt(int(MouseX), int(MouseY)).Sub(pos)
+	c.popup = newMenuPopup(c.th, c.items, nil, func() { c.popup = nil })
+}
+
+// Layout draws the widget, and the menu on top of everything when it is open
+func (c *ContextMenuDef) Layout(gtx C) D {
+	for _, e := range gtx.Events(&c.tag) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press && e.Buttons == pointer.ButtonSecondary {
+			c.open(e.Position.Round())
+		}
+	}
+	for _, e := range gtx.Events(&c.catcher) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+			c.popup = nil
+			if e.Buttons == pointer.ButtonSecondary {
+				c.open(e.Position.Round())
+			}
+		}
+	}
+	dims := c.w(gtx)
+	r := clip.Rect(image.Rectangle{Max: dims.Size}).Push(gtx.Ops)
+	pass := pointer.PassOp{}.Push(gtx.Ops)
+	pointer.InputOp{Tag: &c.tag, Types: pointer.Press}.Add(gtx.Ops)
+	pass.Pop()
+	r.Pop()
+	if c.popup == nil {
+		return dims
+	}
+	macro := op.Record(gtx.Ops)
+	catchOutside(gtx, &c.catcher, c.abs)
+	pgtx := gtx
+	pgtx.Constraints = layout.Constraints{Max: image.Pt(WinX, WinY)}
+	menu := op.Record(gtx.Ops)
+	d := c.popup.layout(pgtx, c.abs.Add(c.pos))
+	call := menu.Stop()
+	// Open upwards/leftwards when there is no space below/to the right
+	p := c.pos
+	if c.abs.X+p.X+d.Size.X > WinX {
+		p.X = Max(-c.abs.X, p.X-d.Size.X)
+	}
+	if c.abs.Y+p.Y+d.Size.Y > WinY {
+		p.Y = Max(-c.abs.Y, p.Y-d.Size.Y)
+	}
+	op.Offset(p).Add(gtx.Ops)
+	call.Add(gtx.Ops)
+	op.Defer(gtx.Ops, macro.Stop())
+	return dims
+}
+
+// MenuBarDef is a horizontal bar of menus, typically at the top of the window
+type MenuBarDef struct {
+	Base
+	menus  []MenuItem
+	clicks []gesture.Click
+	rects  []image.Rectangle
+	open   int
+	popup  *menuPopup
+	// abs is the position of the bar in the window, found from pointer events over the bar
+	abs     image.Point
+	hasAbs  bool
+	posTag  struct{}
+	keyTag  struct{}
+	catcher struct{}
+}
+
+// MenuBar returns a menu bar with one menu for each item. The Items of each menu
+// are shown in a popup when it is clicked, or when Alt and its mnemonic key is pressed.
+func MenuBar(th *Theme, menus ...MenuItem) layout.Widget {
+	b := &MenuBarDef{menus: menus, open: -1}
+	b.th = th
+	b.role = Surface
+	b.clicks = make([]gesture.Click, len(menus))
+	return b.Layout
+}
+
+func (b *MenuBarDef) openMenu(i int, keyboard bool) {
+	n := len(b.menus)
+	i = (i + n) % n
+	b.open = i
+	b.popup = newMenuPopup(b.th, b.menus[i].Items, nil, b.close)
+	b.popup.onSwitch = func(dir int) { b.openMenu(b.open+dir, true) }
+	if keyboard {
+		b.popup.move(1)
+	}
+}
+
+func (b *MenuBarDef) close() {
+	b.open = -1
+	b.popup = nil
+}
+
+// menuAt returns the index of the menu title at pos, or -1
+func (b *MenuBarDef) menuAt(pos image.Point) int {
+	for i, r := range b.rects {
+		if pos.In(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Layout draws the menu bar, and the open menu on top of everything
+func (b *MenuBarDef) Layout(gtx C) D {
+	for _, e := range gtx.Events(&b.posTag) {
+		if e, ok := e.(pointer.Event); ok {
+			b.abs = image.Pt(int(MouseX), int(MouseY)).Sub(e.Position.Round())
+			b.hasAbs = true
+		}
+	}
+	if !b.hasAbs {
+		// Before the pointer has been over the bar, use the position tracked by the layout
+		b.abs = image.Pt(CurrentX, CurrentY)
+	}
+	for _, e := range gtx.Events(&b.keyTag) {
+		if e, ok := e.(key.Event); ok && e.State == key.Press {
+			for i, m := range b.menus {
+				if _, k := mnemonic(m.Text); k == e.Name {
+					b.openMenu(i, true)
+				}
+			}
+		}
+	}
+	for i := range b.clicks {
+		for _, e := range b.clicks[i].Events(gtx) {
+			if e.Type == gesture.TypePress {
+				if b.open == i {
+					b.close()
+				} else {
+					b.openMenu(i, false)
+				}
+			}
+		}
+	}
+	for _, e := range gtx.Events(&b.catcher) {
+		e, ok := e.(pointer.Event)
+		if !ok {
+			continue
+		}
+		// The catcher covers the bar too, so clicks and moves on the titles are found here
+		i := b.menuAt(e.Position.Round())
+		if e.Type == pointer.Press && (i < 0 || i == b.open) {
+			b.close()
+		} else if i >= 0 && i != b.open {
+			b.openMenu(i, false)
+		}
+	}
+
+	th := b.th
+	fg := b.Fg()
+	c := gtx
+	c.Constraints.Min = image.Point{}
+	pad := th.InsidePadding
+	b.rects = b.rects[:0]
+	x := 0
+	calls := make([]op.CallOp, len(b.menus))
+	height := 0
+	for i, m := range b.menus {
+		txt, _ := mnemonic(m.Text)
+		col := fg
+		if gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		macro := op.Record(gtx.Ops)
+		paint.ColorOp{Color: col}.Add(gtx.Ops)
+		dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, txt)
+		calls[i] = macro.Stop()
+		w := dims.Size.X + gtx.Dp(pad.Left+pad.Right)*2
+		height = Max(height, dims.Size.Y+gtx.Dp(pad.Top+pad.Bottom))
+		b.rects = append(b.rects, image.Rect(x, 0, x+w, 0))
+		x += w
+	}
+	for i := range b.rects {
+		b.rects[i].Max.Y = height
+	}
+	bar := image.Rect(0, 0, gtx.Constraints.Max.X, height)
+	paint.FillShape(gtx.Ops, b.Bg(), clip.Rect(bar).Op())
+	if gtx.Queue != nil {
+		r := clip.Rect(bar).Push(gtx.Ops)
+		pass := pointer.PassOp{}.Push(gtx.Ops)
+		pointer.InputOp{Tag: &b.posTag, Types: pointer.Enter | pointer.Move | pointer.Press}.Add(gtx.Ops)
+		pass.Pop()
+		keys := make([]string, 0, len(b.menus))
+		for _, m := range b.menus {
+			if _, k := mnemonic(m.Text); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) > 0 {
+			key.InputOp{Tag: &b.keyTag, Keys: key.Set("Alt-[" + strings.Join(keys, ",") + "]")}.Add(gtx.Ops)
+		}
+		r.Pop()
+	} else {
+		b.close()
+	}
+	for i, r := range b.rects {
+		if i == b.open {
+			paint.FillShape(gtx.Ops, MulAlpha(fg, 40), clip.Rect(r).Op())
+		} else if b.clicks[i].Hovered() {
+			paint.FillShape(gtx.Ops, MulAlpha(fg, 15), clip.Rect(r).Op())
+		}
+		cl := clip.Rect(r).Push(gtx.Ops)
+		b.clicks[i].Add(gtx.Ops)
+		cl.Pop()
+		o := op.Offset(image.Pt(r.Min.X+gtx.Dp(pad.Left+pad.Right), gtx.Dp(pad.Top))).Push(gtx.Ops)
+		calls[i].Add(gtx.Ops)
+		o.Pop()
+	}
+	if b.popup != nil {
+		macro := op.Record(gtx.Ops)
+		catchOutside(gtx, &b.catcher, b.abs)
+		pgtx := gtx
+		pgtx.Constraints = layout.Constraints{Max: image.Pt(WinX, WinY)}
+		p := image.Pt(b.rects[b.open].Min.X, height)
+		menu := op.Record(gtx.Ops)
+		d := b.popup.layout(pgtx, b.abs.Add(p))
+		call := menu.Stop()
+		if b.abs.X+p.X+d.Size.X > WinX {
+			p.X = Max(-b.abs.X, WinX-b.abs.X-d.Size.X)
+		}
+		op.Offset(p).Add(gtx.Ops)
+		call.Add(gtx.Ops)
+		op.Defer(gtx.Ops, macro.Stop())
+	}
+	return D{Size: bar.Max}
+}
+
+func init() {
+	checkIcon, _ = NewIcon(icons.NavigationCheck)
+	submenuIcon, _ = NewIcon(icons.NavigationChevronRight)
+}
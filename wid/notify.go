@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+)
+
+// NotificationDef is a snackbar shown at the bottom of the window by RunWithContext.
+type NotificationDef struct {
+	Base
+	msg         string
+	actions     []notifyAction
+	buttons     []layout.Widget
+	timeout     time.Duration
+	anim        VisibilityAnimation
+	shown       time.Time
+	close       gesture.Click
+	tag         struct{}
+	actionColor color.NRGBA
+}
+
+type notifyAction struct {
+	label string
+	f     func()
+}
+
+// NotifyOption is options specific to notifications
+type NotifyOption func(*NotificationDef)
+
+const (
+	snackbarTimeout   = 4 * time.Second
+	snackbarAnimation = 250 * time.Millisecond
+)
+
+// notifications is the queue of snackbars, guarded by GuiLock. Only the first one is shown.
+var notifications []*NotificationDef
+
+// Notify queues a snackbar with the message msg. It can be called from any goroutine.
+// The options Action(), Timeout() and Role() can be used, like Role(Error) for errors.
+// The default is an inverse surface color.
+func Notify(msg string, options ...Option) {
+	n := &NotificationDef{msg: msg, timeout: snackbarTimeout}
+	n.role = Undefined
+	n.anim.State = Invisible
+	n.anim.Duration = snackbarAnimation
+	n.anim.Easing = Emphasized
+	for _, option := range options {
+		option.apply(n)
+	}
+	GuiLock.Lock()
+	notifications = append(notifications, n)
+	GuiLock.Unlock()
+	Invalidate()
+}
+
+func (o NotifyOption) apply(cfg interface{}) {
+	if n, ok := cfg.(*NotificationDef); ok {
+		o(n)
+	}
+}
+
+// Action adds a button to the snackbar. Clicking it calls f and dismisses the snackbar.
+func Action(label string, f func()) NotifyOption {
+	return func(n *NotificationDef) {
+		n.actions = append(n.actions, notifyAction{label: label, f: f})
+	}
+}
+
+// Timeout sets the time the snackbar is shown. Zero will show it until it is
+// closed by the user, and a close button is added.
+func Timeout(d time.Duration) NotifyOption {
+	return func(n *NotificationDef) {
+		n.timeout = d
+	}
+}
+
+// Dismiss starts hiding the snackbar
+func (n *NotificationDef) Dismiss() {
+	if n.anim.State == Disappearing {
+		return
+	}
+	n.anim.Disappear(time.Now())
+	Invalidate()
+}
+
+// layoutNotifications draws the first queued snackbar, and removes it when it is hidden
+func layoutNotifications(gtx C, th *Theme) {
+	GuiLock.RLock()
+	var n *NotificationDef
+	if len(notifications) > 0 {
+		n = notifications[0]
+	}
+	GuiLock.RUnlock()
+	if n == nil {
+		return
+	}
+	if n.th == nil {
+		n.setup(th)
+		n.anim.Appear(gtx.Now)
+		n.shown = gtx.Now
+	}
+	if n.timeout > 0 && n.anim.State == Visible {
+		if gtx.Now.Sub(n.shown) >= n.timeout+n.anim.Duration {
+			n.anim.Disappear(gtx.Now)
+		} else {
+			op.InvalidateOp{At: n.shown.Add(n.timeout + n.anim.Duration)}.Add(gtx.Ops)
+		}
+	}
+	n.Layout(gtx)
+	if !n.anim.Visible() {
+		GuiLock.Lock()
+		notifications = notifications[1:]
+		GuiLock.Unlock()
+		op.InvalidateOp{}.Add(gtx.Ops)
+	}
+}
+
+func (n *NotificationDef) setup(th *Theme) {
+	n.th = th
+	n.padding = th.InsidePadding
+	n.cornerRadius = th.BorderCornerRadius
+	for _, a := range n.actions {
+		a := a
+		n.buttons = append(n.buttons, TextButton(th, a.label, Fg(&n.actionColor), Do(func() {
+			a.f()
+			n.Dismiss()
+		})))
+	}
+}
+
+// colors returns the foreground, background and action colors. Undefined role gives inverse surface colors.
+func (n *NotificationDef) colors() (fg, bg, action color.NRGBA) {
+	if n.role == Undefined {
+		tone := 80
+		if n.th.DarkMode {
+			tone = 40
+		}
+		return n.th.Bg(Canvas), n.th.Fg(Canvas), Tone(n.th.PrimaryColor, tone)
+	}
+	return n.th.Fg(n.role), n.th.Bg(n.role), n.th.Fg(n.role)
+}
+
+// Layout draws the snackbar at the bottom of the window, sliding up and fading in as it is revealed
+func (n *NotificationDef) Layout(gtx C) D {
+	for _, e := range n.close.Events(gtx) {
+		if e.Type == gesture.TypeClick && n.anim.State != Disappearing {
+			n.anim.Disappear(gtx.Now)
+		}
+	}
+	revealed := n.anim.Revealed(gtx)
+	alpha := uint8(255 * revealed)
+	fg, bg, action := n.colors()
+	fg, bg = MulAlpha(fg, alpha), MulAlpha(bg, alpha)
+	n.actionColor = MulAlpha(action, alpha)
+
+	win := gtx.Constraints.Max
+	margin := gtx.Dp(n.th.ButtonPadding.Bottom) * 2
+	width := Min(win.X-2*margin, gtx.Sp(n.th.TextSize*40))
+	c := gtx
+	c.Constraints = layout.Constraints{Max: image.Pt(width, win.Y)}
+
+	// Buttons to the right of the message
+	macro := op.Record(gtx.Ops)
+	children := make([]layout.FlexChild, 0, len(n.buttons))
+	for _, b := range n.buttons {
+		children = append(children, layout.Rigid(b))
+	}
+	buttons := layout.Flex{Axis: layout.Horizontal}.Layout(c, children...)
+	buttonsCall := macro.Stop()
+	actionsHeight := buttons.Size.Y
+	iconSize := gtx.Sp(n.th.TextSize)
+	if n.timeout == 0 {
+		buttons.Size.X += iconSize * 2
+		buttons.Size.Y = Max(buttons.Size.Y, iconSize*2)
+	}
+
+	padLeft, padTop := gtx.Dp(n.padding.Left), gtx.Dp(n.padding.Top)
+	c.Constraints.Max.X = Max(0, width-buttons.Size.X-padLeft-gtx.Dp(n.padding.Right))
+	macro = op.Record(gtx.Ops)
+	paint.ColorOp{Color: fg}.Add(gtx.Ops)
+	msg := widget.Label{MaxLines: 2}.Layout(c, n.th.Shaper, n.th.DefaultFont, n.th.TextSize, n.msg)
+	msgCall := macro.Stop()
+	height := Max(msg.Size.Y+padTop+gtx.Dp(n.padding.Bottom), buttons.Size.Y)
+
+	pos := image.Pt((win.X-width)/2, win.Y-int(float32(height+margin)*revealed))
+	defer op.Offset(pos).Push(gtx.Ops).Pop()
+	rect := image.Rect(0, 0, width, height)
+	rr := Min(gtx.Dp(n.cornerRadius), height/2)
+	if revealed > 0.5 {
+		DrawShadow(gtx, rect, rr, gtx.Dp(n.th.Elevation)/2)
+	}
+	paint.FillShape(gtx.Ops, bg, clip.UniformRRect(rect, rr).Op(gtx.Ops))
+	// Pointer events on the snackbar must not reach the form below it
+	r := clip.Rect(rect).Push(gtx.Ops)
+	pointer.InputOp{Tag: &n.tag, Types: pointer.Press | pointer.Release}.Add(gtx.Ops)
+	r.Pop()
+
+	o := op.Offset(image.Pt(padLeft, (height-msg.Size.Y)/2)).Push(gtx.Ops)
+	msgCall.Add(gtx.Ops)
+	o.Pop()
+	o = op.Offset(image.Pt(width-buttons.Size.X, (height-actionsHeight)/2)).Push(gtx.Ops)
+	buttonsCall.Add(gtx.Ops)
+	o.Pop()
+	if n.timeout == 0 {
+		p := image.Pt(width-iconSize*3/2, (height-iconSize)/2)
+		o := op.Offset(p).Push(gtx.Ops)
+		ic := gtx
+		ic.Constraints = layout.Exact(image.Pt(iconSize, iconSize))
+		_ = closeIcon.Layout(ic, fg)
+		r := clip.Rect(image.Rectangle{Max: ic.Constraints.Max}.Inset(-iconSize / 4)).Push(gtx.Ops)
+		n.close.Add(gtx.Ops)
+		pointer.CursorPointer.Add(gtx.Ops)
+		r.Pop()
+		o.Pop()
+	}
+	return D{Size: rect.Max}
+}
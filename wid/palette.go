@@ -0,0 +1,430 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gioui.org/gesture"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+)
+
+// Command is an action that can be searched for and run from the command palette.
+type Command struct {
+	// Name is the text shown and searched in the palette
+	Name string
+	// Category is shown in front of the name, like "File" or "View". It is also searched.
+	Category string
+	// Icon is shown to the left of the name when given
+	Icon *Icon
+	// Keys is an optional key combination that runs the command directly. See key.Set.
+	Keys key.Set
+	// Do is called when the command is selected
+	Do       func()
+	shortcut *Shortcut
+}
+
+// PaletteKeys is the key combination that opens the command palette.
+// It must be set before RunWithContext is called.
+var PaletteKeys key.Set = "Short-Shift-P"
+
+const (
+	// paletteRows is the number of commands visible at a time
+	paletteRows = 10
+	// paletteRecent is the number of recently used commands remembered
+	paletteRecent = 8
+)
+
+// commands, recentCommands and palette are guarded by GuiLock
+var (
+	commands        []*Command
+	recentCommands  []*Command
+	palette         *paletteDef
+	paletteShortcut *Shortcut
+)
+
+// paletteDef is the content of the command palette dialog
+type paletteDef struct {
+	Base
+	dialog    *DialogDef
+	edit      *EditDef
+	text      string
+	matches   []*Command
+	selected  int
+	first     int
+	clicks    [paletteRows]gesture.Click
+	keyTag    struct{}
+	scrollTag struct{}
+}
+
+// AddCommand registers a command for the command palette. If c.Keys is given,
+// it is also registered as a shortcut.
+func AddCommand(c Command) *Command {
+	cmd := &c
+	if cmd.Keys != "" {
+		cmd.shortcut = AddShortcut(cmd.Keys, cmd.Title(), cmd.run)
+	}
+	GuiLock.Lock()
+	commands = append(commands, cmd)
+	GuiLock.Unlock()
+	return cmd
+}
+
+// RemoveCommand removes a command and its shortcut
+func RemoveCommand(c *Command) {
+	if c.shortcut != nil {
+		RemoveShortcut(c.shortcut)
+	}
+	GuiLock.Lock()
+	commands = removeCommand(commands, c)
+	recentCommands = removeCommand(recentCommands, c)
+	GuiLock.Unlock()
+}
+
+// Commands returns all registered commands, in the order they were added.
+func Commands() []Command {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	list := make([]Command, len(commands))
+	for i, c := range commands {
+		list[i] = *c
+	}
+	return list
+}
+
+// RecentCommands returns the commands run most recently, the last one first.
+func RecentCommands() []Command {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	list := make([]Command, len(recentCommands))
+	for i, c := range recentCommands {
+		list[i] = *c
+	}
+	return list
+}
+
+// Title returns the category and the name, like "File: Open"
+func (c *Command) Title() string {
+	if c.Category == "" {
+		return c.Name
+	}
+	return c.Category + ": " + c.Name
+}
+
+// run moves the command to the front of the recently used list, and calls it
+func (c *Command) run() {
+	GuiLock.Lock()
+	recentCommands = append([]*Command{c}, removeCommand(recentCommands, c)...)
+	if len(recentCommands) > paletteRecent {
+		recentCommands = recentCommands[:paletteRecent]
+	}
+	GuiLock.Unlock()
+	if c.Do != nil {
+		c.Do()
+	}
+}
+
+func containsCommand(list []*Command, c *Command) bool {
+	for i := range list {
+		if list[i] == c {
+			return true
+		}
+	}
+	return false
+}
+
+func removeCommand(list []*Command, c *Command) []*Command {
+	for i := range list {
+		if list[i] == c {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// addPaletteShortcut registers PaletteKeys. It is called by RunWithContext.
+func addPaletteShortcut(th *Theme) {
+	if paletteShortcut != nil {
+		return
+	}
+	paletteShortcut = AddShortcut(PaletteKeys, "Command palette", func() { OpenPalette(th) },
+		When(func() bool {
+			GuiLock.RLock()
+			defer GuiLock.RUnlock()
+			return len(commands) > 0
+		}))
+}
+
+// OpenPalette shows the command palette, with the recently used commands first.
+// It does nothing if the palette is already open.
+func OpenPalette(th *Theme) {
+	GuiLock.Lock()
+	if palette != nil {
+		GuiLock.Unlock()
+		return
+	}
+	p := &paletteDef{}
+	palette = p
+	GuiLock.Unlock()
+	p.th = th
+	p.edit = new(EditDef)
+	p.edit.setDefaults(th)
+	p.edit.hint = "Type a command"
+	p.edit.Focus()
+	p.update()
+	p.dialog = OpenDialog(th, "", p.Layout, func(int) {
+		GuiLock.Lock()
+		palette = nil
+		GuiLock.Unlock()
+	})
+}
+
+// fuzzyMatch returns true if all runes in pattern are found in s in the same order, ignoring case.
+// The score is higher when the runes are consecutive, or at the start of words.
+func fuzzyMatch(pattern, s string) (score int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	j, last, prev := 0, -2, ' '
+	for i, r := range []rune(strings.ToLower(s)) {
+		if j < len(p) && r == p[j] {
+			score++
+			if i == last+1 {
+				score += 3
+			}
+			if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+				score += 5
+			}
+			last = i
+			j++
+		}
+		prev = r
+	}
+	return score, j == len(p)
+}
+
+// update finds the commands matching the text, best matches first
+func (p *paletteDef) update() {
+	GuiLock.RLock()
+	all := append([]*Command(nil), commands...)
+	recent := append([]*Command(nil), recentCommands...)
+	GuiLock.RUnlock()
+	p.matches = p.matches[:0]
+	p.selected, p.first = 0, 0
+	if strings.TrimSpace(p.text) == "" {
+		p.matches = append(p.matches, recent...)
+		for _, c := range all {
+			if !containsCommand(recent, c) {
+				p.matches = append(p.matches, c)
+			}
+		}
+		return
+	}
+	type scored struct {
+		c     *Command
+		score int
+	}
+	var list []scored
+	for _, c := range all {
+		if score, ok := fuzzyMatch(p.text, c.Title()); ok {
+			// Recently used commands are preferred among equal matches
+			for i := range recent {
+				if recent[i] == c {
+					score += paletteRecent - i
+				}
+			}
+			list = append(list, scored{c, score})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].score > list[j].score })
+	for _, s := range list {
+		p.matches = append(p.matches, s.c)
+	}
+}
+
+// move changes the selection and scrolls it into view
+func (p *paletteDef) move(dir int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	p.selected = Clamp(p.selected+dir, 0, len(p.matches)-1)
+	if p.selected < p.first {
+		p.first = p.selected
+	} else if p.selected >= p.first+paletteRows {
+		p.first = p.selected - paletteRows + 1
+	}
+}
+
+// run closes the palette and runs command i
+func (p *paletteDef) run(i int) {
+	if i < 0 || i >= len(p.matches) {
+		return
+	}
+	p.dialog.Close(-1)
+	p.matches[i].run()
+}
+
+// key handles the navigation keys, and returns true if e was used
+func (p *paletteDef) key(e key.Event) bool {
+	switch e.Name {
+	case key.NameUpArrow, key.NameDownArrow, key.NamePageUp, key.NamePageDown, key.NameReturn, key.NameEnter:
+	default:
+		return false
+	}
+	if e.State != key.Press {
+		return true
+	}
+	switch e.Name {
+	case key.NameUpArrow:
+		p.move(-1)
+	case key.NameDownArrow:
+		p.move(1)
+	case key.NamePageUp:
+		p.move(-paletteRows)
+	case key.NamePageDown:
+		p.move(paletteRows)
+	default:
+		p.run(p.selected)
+	}
+	return true
+}
+
+// paletteQueue takes the navigation keys from the edit field of the palette
+type paletteQueue struct {
+	event.Queue
+	p *paletteDef
+}
+
+func (q paletteQueue) Events(t event.Tag) []event.Event {
+	events := q.Queue.Events(t)
+	filtered := events[:0:0]
+	for _, e := range events {
+		if ke, ok := e.(key.Event); ok && q.p.key(ke) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+func (p *paletteDef) handleEvents(gtx C) {
+	for _, e := range gtx.Events(&p.keyTag) {
+		if e, ok := e.(key.Event); ok {
+			p.key(e)
+		}
+	}
+	for _, e := range gtx.Events(&p.scrollTag) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Scroll {
+			if e.Scroll.Y > 0 {
+				p.first++
+			} else if e.Scroll.Y < 0 {
+				p.first--
+			}
+			p.first = Clamp(p.first, 0, Max(0, len(p.matches)-paletteRows))
+		}
+	}
+	for i := range p.clicks {
+		for _, e := range p.clicks[i].Events(gtx) {
+			if e.Type == gesture.TypeClick {
+				p.run(p.first + i)
+			}
+		}
+	}
+}
+
+// Layout draws the edit field with the matching commands below it
+func (p *paletteDef) Layout(gtx C) D {
+	p.handleEvents(gtx)
+	th := p.th
+	c := gtx
+	if gtx.Queue != nil {
+		c.Queue = paletteQueue{Queue: gtx.Queue, p: p}
+	}
+	edit := p.edit.Layout(c)
+	if t := p.edit.Text(); t != p.text {
+		p.text = t
+		p.update()
+	}
+
+	width := gtx.Constraints.Max.X
+	pad := gtx.Dp(th.InsidePadding.Top)
+	padX := gtx.Dp(th.InsidePadding.Left) * 2
+	iconSize := gtx.Sp(th.TextSize)
+	rowHeight := iconSize + 2*pad
+	GuiLock.RLock()
+	// The height is kept while filtering, so that the dialog does not move
+	rows := Min(len(commands), paletteRows)
+	GuiLock.RUnlock()
+	height := edit.Size.Y + rows*rowHeight
+	if gtx.Queue != nil {
+		area := clip.Rect(image.Rect(0, edit.Size.Y, width, height)).Push(gtx.Ops)
+		key.InputOp{Tag: &p.keyTag, Keys: "↑|↓|⇞|⇟"}.Add(gtx.Ops)
+		pointer.InputOp{Tag: &p.scrollTag, Types: pointer.Scroll, ScrollBounds: image.Rect(0, -inf, 0, inf)}.Add(gtx.Ops)
+		area.Pop()
+	}
+
+	c = gtx
+	c.Constraints.Min = image.Point{}
+	fg := th.Fg(Surface)
+	for i := 0; i < paletteRows && p.first+i < len(p.matches); i++ {
+		cmd := p.matches[p.first+i]
+		row := image.Rect(0, edit.Size.Y+i*rowHeight, width, edit.Size.Y+(i+1)*rowHeight)
+		col := fg
+		if p.first+i == p.selected {
+			col = th.Fg(PrimaryContainer)
+			paint.FillShape(gtx.Ops, th.Bg(PrimaryContainer), clip.UniformRRect(row, pad).Op(gtx.Ops))
+		} else if p.clicks[i].Hovered() {
+			paint.FillShape(gtx.Ops, MulAlpha(fg, 24), clip.UniformRRect(row, pad).Op(gtx.Ops))
+		}
+		if gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		cl := clip.Rect(row).Push(gtx.Ops)
+		p.clicks[i].Add(gtx.Ops)
+		pointer.CursorPointer.Add(gtx.Ops)
+		cl.Pop()
+
+		x := padX
+		if cmd.Icon != nil {
+			ic := gtx
+			ic.Constraints = layout.Exact(image.Pt(iconSize, iconSize))
+			o := op.Offset(image.Pt(x, row.Min.Y+pad)).Push(gtx.Ops)
+			_ = cmd.Icon.Layout(ic, col)
+			o.Pop()
+		}
+		x += iconSize * 3 / 2
+		// The shortcut is right aligned, and the title gets the remaining space
+		shortcutWidth := 0
+		if cmd.Keys != "" {
+			macro := op.Record(gtx.Ops)
+			paint.ColorOp{Color: MulAlpha(col, 160)}.Add(gtx.Ops)
+			c.Constraints.Max.X = width / 2
+			dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, KeyText(cmd.Keys))
+			call := macro.Stop()
+			shortcutWidth = dims.Size.X + padX
+			o := op.Offset(image.Pt(width-padX-dims.Size.X, row.Min.Y+pad)).Push(gtx.Ops)
+			call.Add(gtx.Ops)
+			o.Pop()
+		}
+		c.Constraints.Max.X = Max(0, width-x-shortcutWidth-padX)
+		o := op.Offset(image.Pt(x, row.Min.Y+pad)).Push(gtx.Ops)
+		if cmd.Category != "" {
+			paint.ColorOp{Color: MulAlpha(col, 160)}.Add(gtx.Ops)
+			dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, cmd.Category+": ")
+			op.Offset(image.Pt(dims.Size.X, 0)).Add(gtx.Ops)
+			c.Constraints.Max.X = Max(0, c.Constraints.Max.X-dims.Size.X)
+		}
+		paint.ColorOp{Color: col}.Add(gtx.Ops)
+		_ = widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, cmd.Name)
+		o.Pop()
+	}
+	return D{Size: image.Pt(width, height)}
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		score      int
+		ok         bool
+	}{
+		{"", "Open file", 0, true},
+		{"of", "Open file", 12, true},
+		{"OF", "open file", 12, true},
+		{"ope", "Open", 14, true},
+		{"ope", "Scope", 9, true},
+		{"fo", "Open file", 0, false},
+		{"xyz", "Open file", 0, false},
+		{"open file", "Open", 0, false},
+		{"é", "Café", 1, true},
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyMatch(tt.pattern, tt.s)
+		if ok != tt.ok || ok && score != tt.score {
+			t.Errorf("fuzzyMatch(%q, %q) = %d, %v, want %d, %v", tt.pattern, tt.s, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyMatchOrder(t *testing.T) {
+	// Each pattern must give a higher score to the first text than to the second
+	tests := []struct {
+		pattern, better, worse string
+	}{
+		{"save", "Save", "Unsaved"},
+		{"sa", "Save as", "Mosaic"},
+		{"fs", "File: Save", "Files"},
+		{"tab", "Tab next", "Settings: table"},
+	}
+	for _, tt := range tests {
+		b, ok1 := fuzzyMatch(tt.pattern, tt.better)
+		w, ok2 := fuzzyMatch(tt.pattern, tt.worse)
+		if !ok1 || !ok2 || b <= w {
+			t.Errorf("%q: %q scores %d, %q scores %d", tt.pattern, tt.better, b, tt.worse, w)
+		}
+	}
+}
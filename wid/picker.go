@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"errors"
+	"image"
+	"unicode/utf8"
+
+	"gioui.org/gesture"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+)
+
+// pickerContent is the part of a picker that depends on the type of value
+type pickerContent interface {
+	// format returns the value as shown in the edit field
+	format() string
+	// mask returns the text typed by the user, with the separators added
+	mask(s string) string
+	// store saves the value of the text. It returns errIncomplete while the user is typing.
+	store(s string) error
+	// opened is called when the popup is opened
+	opened()
+	// popupKeys is the keys used by the popup
+	popupKeys() key.Set
+	popupKey(e key.Event)
+	popupPointer(e pointer.Event)
+	layoutPopup(gtx C) D
+}
+
+var errIncomplete = errors.New("incomplete value")
+
+// picker is an edit field with an icon that opens a popup. It is used by
+// DatePicker, TimePicker and DurationPicker.
+type picker struct {
+	Base
+	edit     *EditDef
+	content  pickerContent
+	icon     *Icon
+	lastText string
+	// The icon opens the popup, and can be focused by Tab
+	iconClick   gesture.Click
+	iconKey     struct{}
+	iconFocused bool
+	focusIcon   bool
+	// Popup state
+	open       bool
+	focusPopup bool
+	tag        struct{}
+	keyTag     struct{}
+	catcher    struct{}
+	popupTag   struct{}
+}
+
+// init sets up the edit field. Base options like W(), Lbl(), Hint() and TabIndex()
+// are used by the edit field, and all options by cfg.
+func (p *picker) init(th *Theme, content pickerContent, icon *Icon, cfg interface{}, options []Option) {
+	p.th = th
+	p.content = content
+	p.icon = icon
+	p.edit = new(EditDef)
+	p.edit.setDefaults(th)
+	for _, option := range options {
+		option.apply(cfg)
+		if _, ok := option.(BaseOption); ok {
+			option.apply(p.edit)
+		}
+	}
+	// Changes are reported when a complete value is entered, not for each key
+	p.edit.onUserChange = nil
+}
+
+func (p *picker) openPopup() {
+	p.open = true
+	p.focusPopup = true
+	p.content.opened()
+}
+
+// closePopup hides the popup. The icon gets the focus when refocus is set.
+func (p *picker) closePopup(refocus bool) {
+	p.open = false
+	p.focusIcon = refocus
+}
+
+// changed calls the Do() handler after the value was changed by the user
+func (p *picker) changed() {
+	p.lastText = p.content.format()
+	if p.onUserChange != nil {
+		p.onUserChange()
+	}
+}
+
+// updateText applies the mask to the text typed by the user, and stores complete values
+func (p *picker) updateText() {
+	s := p.edit.Text()
+	if s == p.lastText {
+		return
+	}
+	if m := p.content.mask(s); m != s {
+		s = m
+		p.edit.SetText(s)
+		n := utf8.RuneCountInString(s)
+		p.edit.SetCaret(n, n)
+	}
+	p.lastText = s
+	p.edit.outlineColor = p.th.Fg(Outline)
+	if err := p.content.store(s); err == nil {
+		p.changed()
+	} else if err != errIncomplete {
+		p.edit.outlineColor = p.th.Bg(Error)
+	}
+}
+
+func (p *picker) handleEvents(gtx C) {
+	for _, e := range p.iconClick.Events(gtx) {
+		if e.Type == gesture.TypeClick {
+			if p.open {
+				p.closePopup(false)
+			} else {
+				p.openPopup()
+			}
+		}
+	}
+	for _, e := range gtx.Events(&p.iconKey) {
+		switch e := e.(type) {
+		case key.FocusEvent:
+			p.iconFocused = e.Focus
+		case key.Event:
+			if e.State == key.Press && !p.open {
+				p.openPopup()
+			}
+		}
+	}
+	for _, e := range gtx.Events(&p.catcher) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Press {
+			p.closePopup(false)
+		}
+	}
+	for _, e := range gtx.Events(&p.tag) {
+		if e, ok := e.(pointer.Event); ok {
+			p.content.popupPointer(e)
+		}
+	}
+	for _, e := range gtx.Events(&p.keyTag) {
+		switch e := e.(type) {
+		case key.FocusEvent:
+			if !e.Focus && p.open && !p.focusPopup {
+				p.closePopup(false)
+			}
+		case key.Event:
+			if e.State == key.Press && e.Name == key.NameEscape {
+				p.closePopup(true)
+			} else if e.State == key.Press {
+				p.content.popupKey(e)
+			}
+		}
+	}
+}
+
+// Layout draws the edit field with the icon to the right, and the popup when it is open
+func (p *picker) Layout(gtx C) D {
+	p.handleEvents(gtx)
+	if gtx.Queue == nil {
+		p.open = false
+	}
+	// Show the value when the user is not typing
+	if !p.edit.Focused() {
+		if s := p.content.format(); s != p.edit.Text() {
+			p.edit.SetText(s)
+			p.edit.outlineColor = p.th.Fg(Outline)
+		}
+		p.lastText = p.edit.Text()
+	}
+	iconSize := gtx.Sp(p.th.TextSize * 1.5)
+	iconWidth := iconSize + gtx.Dp(p.th.InsidePadding.Right)
+	c := gtx
+	c.Constraints.Max.X = Max(0, c.Constraints.Max.X-iconWidth)
+	c.Constraints.Min.X = Min(Max(0, c.Constraints.Min.X-iconWidth), c.Constraints.Max.X)
+	dims := p.edit.Layout(c)
+	if p.edit.Focused() {
+		p.updateText()
+	}
+
+	// The icon is centered on the edit border
+	top, bottom := gtx.Dp(p.edit.padding.Top), dims.Size.Y-gtx.Dp(p.edit.padding.Bottom)
+	icon := image.Rect(dims.Size.X, (top+bottom-iconSize)/2, dims.Size.X+iconSize, (top+bottom+iconSize)/2)
+	col := p.th.Fg(Canvas)
+	if gtx.Queue == nil {
+		col = Disabled(col)
+	}
+	if p.iconClick.Hovered() || p.open {
+		paint.FillShape(gtx.Ops, MulAlpha(col, 24), clip.UniformRRect(icon, iconSize/2).Op(gtx.Ops))
+	}
+	if p.iconFocused {
+		paintFocusRing(gtx, p.th, icon, iconSize/2)
+	}
+	o := op.Offset(icon.Min.Add(image.Pt(iconSize/8, iconSize/8))).Push(gtx.Ops)
+	ic := gtx
+	ic.Constraints = layout.Exact(image.Pt(iconSize*3/4, iconSize*3/4))
+	_ = p.icon.Layout(ic, col)
+	o.Pop()
+	r := clip.Rect(icon).Push(gtx.Ops)
+	p.iconClick.Add(gtx.Ops)
+	pointer.CursorPointer.Add(gtx.Ops)
+	if gtx.Queue != nil {
+		keys := key.Set("")
+		if p.iconFocused {
+			keys = "⏎|Space|Alt-↓"
+		}
+		key.InputOp{Tag: &p.iconKey, Keys: keys}.Add(gtx.Ops)
+		if p.focusIcon {
+			key.FocusOp{Tag: &p.iconKey}.Add(gtx.Ops)
+			p.focusIcon = false
+		}
+	} else {
+		p.iconFocused = false
+	}
+	r.Pop()
+	size := image.Pt(dims.Size.X+iconWidth, dims.Size.Y)
+
+	if p.open {
+		macro := op.Record(gtx.Ops)
+		popup := p.content.layoutPopup(gtx)
+		call := macro.Stop()
+		// Open upwards when there is no space below, like the DropDown list
+		pos := image.Pt(Max(0, size.X-popup.Size.X), bottom)
+		if WinY-CurrentY < popup.Size.Y+size.Y {
+			pos.Y = top - popup.Size.Y
+		}
+		macro = op.Record(gtx.Ops)
+		// Clicks outside the popup close it
+		r := clip.Rect(image.Rect(-inf, -inf, inf, inf)).Push(gtx.Ops)
+		pointer.InputOp{Tag: &p.catcher, Types: pointer.Press}.Add(gtx.Ops)
+		r.Pop()
+		o := op.Offset(pos).Push(gtx.Ops)
+		drawPopup(gtx, p.th, popup.Size, call, &p.popupTag)
+		r = clip.Rect(image.Rectangle{Max: popup.Size}).Push(gtx.Ops)
+		pointer.InputOp{
+			Tag:          &p.tag,
+			Types:        pointer.Press | pointer.Move | pointer.Leave | pointer.Scroll,
+			ScrollBounds: image.Rect(0, -inf, 0, inf),
+		}.Add(gtx.Ops)
+		key.InputOp{Tag: &p.keyTag, Keys: p.content.popupKeys() + "|⎋"}.Add(gtx.Ops)
+		if p.focusPopup {
+			key.FocusOp{Tag: &p.keyTag}.Add(gtx.Ops)
+			p.focusPopup = false
+		}
+		r.Pop()
+		o.Pop()
+		op.Defer(gtx.Ops, macro.Stop())
+	}
+	return D{Size: size}
+}
@@ -3,54 +3,247 @@
 package wid
 
 import (
+	"fmt"
 	"image"
+	"image/color"
+	"math"
+	"time"
 
+	"gioui.org/f32"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
+	"gioui.org/widget"
 )
 
 // ProgressBarStyle defines the progress bar
 type ProgressBarStyle struct {
 	Base
 	Progress *float32
+	// buffer is a secondary value drawn behind the progress, like the buffered part of a video
+	buffer   *float32
+	circular bool
+	label    bool
 }
 
-// ProgressBar returns a widget for a progress bar
+// ProgressOption is options specific to progress bars
+type ProgressOption func(*ProgressBarStyle)
+
+const (
+	// Duration of one pass of the indeterminate animations
+	linearPeriod   = 1500 * time.Millisecond
+	circularPeriod = 1400 * time.Millisecond
+)
+
+// ProgressBar returns a widget for a progress bar. Progress goes from 0 to 1. When progress
+// is nil or negative, the progress is unknown and an indeterminate animation is shown.
+// The W() option sets the thickness of the bar. The bar is drawn in the foreground color
+// of the role, which is SurfaceVariant unless the Role() or Fg() option is given.
 func ProgressBar(th *Theme, progress *float32, options ...Option) func(gtx C) D {
 	p := &ProgressBarStyle{
 		Progress: progress,
 	}
+	p.th = th
 	p.cornerRadius = unit.Dp(10)
 	p.width = 10
-	p.role = Primary
-	p.Apply(options...)
 	p.role = SurfaceVariant
-	p.width = 10
+	p.padding = layout.UniformInset(unit.Dp(2))
+	for _, option := range options {
+		option.apply(p)
+	}
+	return func(gtx C) D {
+		return p.layout(gtx)
+	}
+}
+
+// ProgressCircle returns a circular progress indicator. Progress goes from 0 to 1. When progress
+// is nil or negative, a spinner is shown. The W() option sets the diameter of the circle.
+// The color is chosen like for ProgressBar().
+func ProgressCircle(th *Theme, progress *float32, options ...Option) func(gtx C) D {
+	p := &ProgressBarStyle{
+		Progress: progress,
+		circular: true,
+	}
 	p.th = th
+	p.width = 48
+	p.role = SurfaceVariant
+	p.padding = layout.UniformInset(unit.Dp(2))
+	for _, option := range options {
+		option.apply(p)
+	}
 	return func(gtx C) D {
 		return p.layout(gtx)
 	}
 }
 
-func (p ProgressBarStyle) layout(gtx C) D {
-	progressBarWidth := gtx.Constraints.Min.X - gtx.Dp(4)
-	return layout.UniformInset(unit.Dp(2)).Layout(gtx, func(gtx C) D {
-		GuiLock.RLock()
-		value := *p.Progress
-		GuiLock.RUnlock()
-		width := int(float32(progressBarWidth) * Clamp(value, 0, 1))
-		color := p.Fg()
-		if gtx.Queue == nil {
-			color = Disabled(color)
-		}
-		rr := rr(gtx, p.cornerRadius, gtx.Dp(p.width))
-		d := image.Point{X: width, Y: gtx.Dp(p.width)}
-		height := p.width
-		defer clip.UniformRRect(image.Rectangle{Max: image.Pt(width, gtx.Dp(height))}, rr).Push(gtx.Ops).Pop()
-		paint.ColorOp{Color: color}.Add(gtx.Ops)
-		paint.PaintOp{}.Add(gtx.Ops)
-		return D{Size: d}
-	})
+func (o ProgressOption) apply(cfg interface{}) {
+	if p, ok := cfg.(*ProgressBarStyle); ok {
+		o(p)
+	}
+}
+
+// Buffer adds a secondary value, drawn in a lighter color behind the progress
+func Buffer(value *float32) ProgressOption {
+	return func(p *ProgressBarStyle) {
+		p.buffer = value
+	}
+}
+
+// PercentLabel shows the progress as a percentage in the center of the bar or circle
+func PercentLabel() ProgressOption {
+	return func(p *ProgressBarStyle) {
+		p.label = true
+	}
+}
+
+// values returns the progress and the buffer, with -1 for an unknown progress
+func (p *ProgressBarStyle) values() (value, buffer float32) {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	value = -1
+	if p.Progress != nil {
+		value = *p.Progress
+	}
+	if p.buffer != nil {
+		buffer = *p.buffer
+	}
+	return value, buffer
+}
+
+// color returns the color of the indicator, given by the Fg() option or the role
+func (p *ProgressBarStyle) color(gtx C) color.NRGBA {
+	c := p.Fg()
+	if gtx.Queue == nil {
+		c = Disabled(c)
+	}
+	return c
+}
+
+// phase returns how far the animation has come in the current period, from 0 to 1.
+// It requests a new frame, except for disabled widgets which are not animated.
+func phase(gtx C, period time.Duration) float32 {
+	if gtx.Queue == nil {
+		return 0
+	}
+	op.InvalidateOp{}.Add(gtx.Ops)
+	return float32(gtx.Now.UnixNano()%int64(period)) / float32(period)
+}
+
+func (p *ProgressBarStyle) layout(gtx C) D {
+	p.CheckDisable(gtx)
+	defer op.Offset(image.Pt(gtx.Dp(p.padding.Left), gtx.Dp(p.padding.Top))).Push(gtx.Ops).Pop()
+	var size image.Point
+	if p.circular {
+		size = p.layoutCircle(gtx)
+	} else {
+		size = p.layoutBar(gtx)
+	}
+	return D{Size: size.Add(image.Pt(
+		gtx.Dp(p.padding.Left+p.padding.Right),
+		gtx.Dp(p.padding.Top+p.padding.Bottom)))}
+}
+
+func (p *ProgressBarStyle) layoutBar(gtx C) image.Point {
+	value, buffer := p.values()
+	width := Max(0, gtx.Constraints.Min.X-gtx.Dp(p.padding.Left+p.padding.Right))
+	height := gtx.Dp(p.width)
+	showLabel := p.label && value >= 0
+	if showLabel {
+		height = Max(height, gtx.Sp(p.th.TextSize*1.3))
+	}
+	col := p.color(gtx)
+	rr := rr(gtx, p.cornerRadius, height)
+	defer clip.UniformRRect(image.Rectangle{Max: image.Pt(width, height)}, rr).Push(gtx.Ops).Pop()
+	paint.Fill(gtx.Ops, MulAlpha(col, 60))
+	// bar returns the part of the bar from a to b, as fractions of the width
+	bar := func(a, b float32) image.Rectangle {
+		return image.Rect(int(Clamp(a, 0, 1)*float32(width)), 0, int(Clamp(b, 0, 1)*float32(width)), height)
+	}
+	if buffer > 0 {
+		paint.FillShape(gtx.Ops, MulAlpha(col, 110), clip.Rect(bar(0, buffer)).Op())
+	}
+	if value < 0 {
+		// A segment of 30% of the width slides over the bar
+		t := phase(gtx, linearPeriod)*1.3 - 0.3
+		paint.FillShape(gtx.Ops, col, clip.Rect(bar(t, t+0.3)).Op())
+		return image.Pt(width, height)
+	}
+	done := bar(0, value)
+	paint.FillShape(gtx.Ops, col, clip.Rect(done).Op())
+	if showLabel {
+		// The label is drawn in two colors, for contrast with the bar and with the track
+		macro := op.Record(gtx.Ops)
+		lbl := p.percentLabel(gtx, p.th.TextSize)
+		call := macro.Stop()
+		o := op.Offset(image.Pt(width, height).Sub(lbl).Div(2)).Push(gtx.Ops)
+		left := done.Sub(image.Pt(width, height).Sub(lbl).Div(2))
+		cl := clip.Rect(left).Push(gtx.Ops)
+		paint.ColorOp{Color: ColDisabled(p.th.Bg(p.role), gtx.Queue == nil)}.Add(gtx.Ops)
+		call.Add(gtx.Ops)
+		cl.Pop()
+		cl = clip.Rect(image.Rect(left.Max.X, 0, inf, inf)).Push(gtx.Ops)
+		paint.ColorOp{Color: ColDisabled(p.th.Fg(Canvas), gtx.Queue == nil)}.Add(gtx.Ops)
+		call.Add(gtx.Ops)
+		cl.Pop()
+		o.Pop()
+	}
+	return image.Pt(width, height)
+}
+
+func (p *ProgressBarStyle) layoutCircle(gtx C) image.Point {
+	value, buffer := p.values()
+	d := gtx.Dp(p.width)
+	thickness := float32(Max(1, d/12))
+	center := f32.Pt(float32(d)/2, float32(d)/2)
+	radius := (float32(d) - thickness) / 2
+	col := p.color(gtx)
+	const top = -math.Pi / 2
+	paint.FillShape(gtx.Ops, MulAlpha(col, 60), arc(gtx, center, radius, thickness, top, 2*math.Pi))
+	if buffer > 0 {
+		paint.FillShape(gtx.Ops, MulAlpha(col, 110), arc(gtx, center, radius, thickness, top, Clamp(buffer, 0, 1)*2*math.Pi))
+	}
+	if value < 0 {
+		// The arc turns around, while it grows and shrinks at half the speed
+		t := phase(gtx, circularPeriod)
+		grow := phase(gtx, 2*circularPeriod)
+		length := math.Pi * (0.1 + 0.65*(0.5-0.5*float32(math.Cos(2*math.Pi*float64(grow)))))
+		paint.FillShape(gtx.Ops, col, arc(gtx, center, radius, thickness, top+2*math.Pi*t, length))
+		return image.Pt(d, d)
+	}
+	if value > 0 {
+		paint.FillShape(gtx.Ops, col, arc(gtx, center, radius, thickness, top, Clamp(value, 0, 1)*2*math.Pi))
+	}
+	if p.label {
+		macro := op.Record(gtx.Ops)
+		paint.ColorOp{Color: ColDisabled(p.th.Fg(Canvas), gtx.Queue == nil)}.Add(gtx.Ops)
+		lbl := p.percentLabel(gtx, Min(p.th.TextSize, unit.Sp(float32(p.width)/3.5)))
+		call := macro.Stop()
+		defer op.Offset(image.Pt(d, d).Sub(lbl).Div(2)).Push(gtx.Ops).Pop()
+		call.Add(gtx.Ops)
+	}
+	return image.Pt(d, d)
+}
+
+// percentLabel draws the progress in percent, using the current color
+func (p *ProgressBarStyle) percentLabel(gtx C, size unit.Sp) image.Point {
+	value, _ := p.values()
+	c := gtx
+	c.Constraints = layout.Constraints{Max: image.Pt(inf, inf)}
+	txt := fmt.Sprintf("%d%%", int(Clamp(value, 0, 1)*100+0.5))
+	return widget.Label{MaxLines: 1}.Layout(c, p.th.Shaper, p.th.DefaultFont, size, txt).Size
+}
+
+// arc returns a stroke along the circle with the given center and radius. The angles are in
+// radians, clockwise from the x axis.
+func arc(gtx C, center f32.Point, radius, width, start, sweep float32) clip.Op {
+	var path clip.Path
+	path.Begin(gtx.Ops)
+	s := f32.Pt(
+		center.X+radius*float32(math.Cos(float64(start))),
+		center.Y+radius*float32(math.Sin(float64(start))))
+	path.MoveTo(s)
+	path.Arc(center.Sub(s), center.Sub(s), sweep)
+	return clip.Stroke{Path: path.End(), Width: width}.Op()
 }
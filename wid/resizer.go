@@ -4,10 +4,13 @@ import (
 	"image"
 
 	"gioui.org/gesture"
+	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
+	"gioui.org/unit"
 )
 
 // Resize provides a draggable handle in between two widgets for resizing their area.
@@ -97,3 +100,351 @@ func (rs *Resize) drawSash(gtx C) image.Point {
 	paint.PaintOp{}.Add(gtx.Ops)
 	return dims
 }
+
+// Pane is one of the widgets in a Split
+type Pane struct {
+	W layout.Widget
+	// Min and Max limit the size of the pane along the axis. Zero Max means no limit.
+	Min, Max unit.Dp
+	// Ratio is the initial share of the space. Panes without a ratio get an equal share.
+	Ratio float32
+	// ref is set by the SplitRef option
+	ref **SplitDef
+}
+
+// SplitDef lays out any number of panes along an axis, with a draggable sash between them.
+// Double clicking a sash collapses the smaller of its panes, or restores a collapsed pane.
+// A focused sash is moved by the arrow keys, and Enter or Space collapses or restores.
+type SplitDef struct {
+	th     *Theme
+	axis   layout.Axis
+	panes  []Pane
+	ratios []float32
+	// saved is the ratio of a collapsed pane before it was collapsed, and 0 for other panes
+	saved  []float32
+	sashes []*sash
+	sizes  []int
+}
+
+type sash struct {
+	drag       gesture.Drag
+	click      gesture.Click
+	start      float32
+	startSizes []int
+	keyTag     struct{}
+	focused    bool
+	request    focusRequest
+}
+
+// SplitRef is an option for Split that stores the split in s, so that Ratios and
+// SetRatios can be used to save and restore the layout. It is given in the list of panes,
+// but does not add a pane.
+func SplitRef(s **SplitDef) Pane {
+	return Pane{ref: s}
+}
+
+// Split returns a container with the panes side by side along axis.
+func Split(th *Theme, axis layout.Axis, panes ...Pane) layout.Widget {
+	var refs []**SplitDef
+	var list []Pane
+	for _, p := range panes {
+		if p.ref != nil {
+			refs = append(refs, p.ref)
+		} else {
+			list = append(list, p)
+		}
+	}
+	s := newSplit(th, axis, list...)
+	for _, ref := range refs {
+		*ref = s
+	}
+	return s.Layout
+}
+
+func newSplit(th *Theme, axis layout.Axis, panes ...Pane) *SplitDef {
+	s := &SplitDef{th: th, axis: axis, panes: panes}
+	s.ratios = make([]float32, len(panes))
+	s.saved = make([]float32, len(panes))
+	for i, p := range panes {
+		s.ratios[i] = p.Ratio
+		if p.Ratio <= 0 {
+			s.ratios[i] = 1 / float32(len(panes))
+		}
+	}
+	normalize(s.ratios)
+	for i := 1; i < len(panes); i++ {
+		s.sashes = append(s.sashes, &sash{})
+	}
+	return s
+}
+
+// normalize scales r so that the sum is 1
+func normalize(r []float32) {
+	var sum float32
+	for _, v := range r {
+		sum += v
+	}
+	for i := range r {
+		if sum > 0 {
+			r[i] /= sum
+		} else {
+			r[i] = 1 / float32(len(r))
+		}
+	}
+}
+
+// Ratios returns the share of the space used by each pane, with 0 for collapsed panes.
+func (s *SplitDef) Ratios() []float32 {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return append([]float32(nil), s.ratios...)
+}
+
+// SetRatios sets the share of the space for each pane, as returned by Ratios.
+// The values are normalized, and panes with ratio 0 are collapsed.
+// When no pane has a ratio, the panes get an equal share.
+func (s *SplitDef) SetRatios(r []float32) {
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	shown := false
+	for i := range s.ratios {
+		s.ratios[i], s.saved[i] = 0, 0
+		if i < len(r) && r[i] > 0 {
+			s.ratios[i] = r[i]
+			shown = true
+		} else {
+			s.saved[i] = 1 / float32(len(s.ratios))
+		}
+	}
+	if !shown {
+		for i := range s.saved {
+			s.saved[i] = 0
+		}
+	}
+	normalize(s.ratios)
+	Invalidate()
+}
+
+// limits returns the minimum and maximum size of pane i in pixels
+func (s *SplitDef) limits(gtx C, i int) (int, int) {
+	max := inf * 100
+	if s.panes[i].Max > 0 {
+		max = gtx.Dp(s.panes[i].Max)
+	}
+	return gtx.Dp(s.panes[i].Min), max
+}
+
+// calcSizes converts the ratios to sizes in pixels, within the limits of each pane.
+func (s *SplitDef) calcSizes(gtx C, avail int) []int {
+	GuiLock.RLock()
+	ratios := append([]float32(nil), s.ratios...)
+	saved := append([]float32(nil), s.saved...)
+	GuiLock.RUnlock()
+	sizes := make([]int, len(ratios))
+	sum := 0
+	for i, r := range ratios {
+		sizes[i] = int(r*float32(avail) + 0.5)
+		if saved[i] == 0 {
+			min, max := s.limits(gtx, i)
+			sizes[i] = Clamp(sizes[i], min, max)
+		}
+		sum += sizes[i]
+	}
+	// Give the rest of the space to the panes that can take it, starting with the last
+	diff := avail - sum
+	for i := len(sizes) - 1; i >= 0 && diff != 0; i-- {
+		if saved[i] > 0 {
+			continue
+		}
+		min, max := s.limits(gtx, i)
+		n := Clamp(sizes[i]+diff, min, max)
+		diff -= n - sizes[i]
+		sizes[i] = n
+	}
+	return sizes
+}
+
+// setSizes stores the sizes as ratios. Panes given a size are no longer collapsed.
+func (s *SplitDef) setSizes(sizes []int) {
+	sum := 0
+	for _, v := range sizes {
+		sum += v
+	}
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	for i, v := range sizes {
+		s.ratios[i] = float32(v) / float32(Max(1, sum))
+		if v > 0 {
+			s.saved[i] = 0
+		}
+	}
+}
+
+// moveSash moves sash i by delta pixels from the given sizes, within the limits of both panes
+func (s *SplitDef) moveSash(gtx C, i int, sizes []int, delta int) {
+	sizes = append([]int(nil), sizes...)
+	minA, maxA := s.limits(gtx, i)
+	minB, maxB := s.limits(gtx, i+1)
+	a, b := sizes[i], sizes[i+1]
+	lo, hi := Max(minA-a, b-maxB), Min(maxA-a, b-minB)
+	if lo > hi {
+		return
+	}
+	delta = Clamp(delta, lo, hi)
+	sizes[i], sizes[i+1] = a+delta, b-delta
+	s.setSizes(sizes)
+}
+
+// toggle collapses the smaller pane next to sash i, or restores a collapsed pane
+func (s *SplitDef) toggle(i int) {
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	a, b := i, i+1
+	switch {
+	case s.saved[a] > 0:
+		s.restore(a, b)
+	case s.saved[b] > 0:
+		s.restore(b, a)
+	default:
+		if s.ratios[b] < s.ratios[a] {
+			a, b = b, a
+		}
+		s.saved[a] = Max(s.ratios[a], 0.01)
+		s.ratios[b] += s.ratios[a]
+		s.ratios[a] = 0
+	}
+}
+
+// restore gives the collapsed pane c its size back, taken from the pane next to it
+func (s *SplitDef) restore(c, from int) {
+	r := Min(s.saved[c], s.ratios[from])
+	s.saved[c] = 0
+	s.ratios[c] = r
+	s.ratios[from] -= r
+}
+
+// Layout draws the panes and the sashes between them
+func (s *SplitDef) Layout(gtx C) D {
+	th := s.th
+	size := gtx.Constraints.Max
+	total := s.axis.Convert(size).X
+	cross := s.axis.Convert(size).Y
+	sashWidth := gtx.Dp(th.SashWidth)
+	avail := Max(0, total-sashWidth*len(s.sashes))
+	if len(s.sizes) != len(s.panes) {
+		s.sizes = s.calcSizes(gtx, avail)
+	}
+	for i, sh := range s.sashes {
+		s.sashEvents(gtx, i, sh)
+	}
+	s.sizes = s.calcSizes(gtx, avail)
+
+	pos := 0
+	for i, p := range s.panes {
+		if s.sizes[i] > 0 {
+			o := op.Offset(s.axis.Convert(image.Pt(pos, 0))).Push(gtx.Ops)
+			c := gtx
+			c.Constraints = layout.Exact(s.axis.Convert(image.Pt(s.sizes[i], cross)))
+			cl := clip.Rect{Max: c.Constraints.Max}.Push(gtx.Ops)
+			p.W(c)
+			cl.Pop()
+			o.Pop()
+		}
+		pos += s.sizes[i]
+		if i < len(s.sashes) {
+			s.layoutSash(gtx, s.sashes[i], image.Rectangle{
+				Min: s.axis.Convert(image.Pt(pos, 0)),
+				Max: s.axis.Convert(image.Pt(pos+sashWidth, cross)),
+			})
+			pos += sashWidth
+		}
+	}
+	return D{Size: size}
+}
+
+func (s *SplitDef) sashEvents(gtx C, i int, sh *sash) {
+	for _, e := range sh.click.Events(gtx) {
+		if e.Type == gesture.TypeClick && e.NumClicks == 2 {
+			s.toggle(i)
+		}
+	}
+	for _, e := range sh.drag.Events(gtx.Metric, gtx, gesture.Axis(s.axis)) {
+		xy := e.Position.X
+		if s.axis == layout.Vertical {
+			xy = e.Position.Y
+		}
+		switch e.Type {
+		case pointer.Press:
+			key.FocusOp{Tag: &sh.keyTag}.Add(gtx.Ops)
+			sh.start = xy
+			sh.startSizes = append(sh.startSizes[:0], s.sizes...)
+		case pointer.Drag:
+			if len(sh.startSizes) == len(s.sizes) {
+				s.moveSash(gtx, i, sh.startSizes, int(xy-sh.start))
+			}
+		}
+	}
+	for _, e := range gtx.Events(&sh.keyTag) {
+		switch e := e.(type) {
+		case key.FocusEvent:
+			sh.focused = e.Focus
+		case key.Event:
+			if e.State != key.Press {
+				continue
+			}
+			step := Max(1, s.axis.Convert(gtx.Constraints.Max).X/100)
+			if e.Modifiers.Contain(key.ModCtrl) {
+				step *= 10
+			}
+			switch e.Name {
+			case key.NameLeftArrow, key.NameUpArrow:
+				s.moveSash(gtx, i, s.sizes, -step)
+			case key.NameRightArrow, key.NameDownArrow:
+				s.moveSash(gtx, i, s.sizes, step)
+			case key.NameReturn, key.NameEnter, key.NameSpace:
+				s.toggle(i)
+			}
+		}
+	}
+}
+
+func (s *SplitDef) layoutSash(gtx C, sh *sash, r image.Rectangle) {
+	paint.FillShape(gtx.Ops, s.th.SashColor, clip.Rect(r).Op())
+	if sh.focused {
+		paintFocusRing(gtx, s.th, r, 0)
+	}
+	defer clip.Rect(r).Push(gtx.Ops).Pop()
+	sh.drag.Add(gtx.Ops)
+	sh.click.Add(gtx.Ops)
+	if s.axis == layout.Horizontal {
+		pointer.CursorColResize.Add(gtx.Ops)
+	} else {
+		pointer.CursorRowResize.Add(gtx.Ops)
+	}
+	if gtx.Queue == nil {
+		sh.focused = false
+		return
+	}
+	keys := key.Set("")
+	if sh.focused {
+		keys = "(Ctrl)-[←,→,↑,↓]|⏎|⌤|Space"
+	}
+	key.InputOp{Tag: &sh.keyTag, Keys: keys}.Add(gtx.Ops)
+	sh.request.apply(gtx, &sh.keyTag, sh.focused)
+	registerFocus(gtx, sh, 0)
+}
+
+// Focus moves the keyboard focus to the sash
+func (sh *sash) Focus() {
+	sh.request.set(focusRequested)
+}
+
+// Blur removes the keyboard focus from the sash
+func (sh *sash) Blur() {
+	sh.request.set(blurRequested)
+}
+
+// Focused returns true if the sash has the keyboard focus
+func (sh *sash) Focused() bool {
+	return sh.focused
+}
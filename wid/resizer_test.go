@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"math"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+// testSplit returns a split of the panes, with ratios set when given
+func testSplit(panes []Pane, ratios []float32) *SplitDef {
+	s := newSplit(&Theme{}, layout.Horizontal, panes...)
+	if ratios != nil {
+		s.SetRatios(ratios)
+	}
+	return s
+}
+
+func equalRatios(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-4 {
+			return false
+		}
+	}
+	return true
+}
+
+func equalSizes(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplitRef(t *testing.T) {
+	var s *SplitDef
+	Split(&Theme{}, layout.Vertical, Pane{Ratio: 1}, SplitRef(&s), Pane{Ratio: 3})
+	if s == nil || len(s.panes) != 2 || !equalRatios(s.Ratios(), []float32{0.25, 0.75}) {
+		t.Fatalf("got %+v", s)
+	}
+}
+
+func TestSplitCalcSizes(t *testing.T) {
+	gtx := layout.Context{Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1}}
+	tests := []struct {
+		name   string
+		panes  []Pane
+		ratios []float32
+		avail  int
+		want   []int
+	}{
+		{"equal", []Pane{{}, {}, {}}, nil, 300, []int{100, 100, 100}},
+		{"ratios", []Pane{{Ratio: 2}, {Ratio: 1}, {Ratio: 1}}, nil, 400, []int{200, 100, 100}},
+		{"min taken from last", []Pane{{Min: 150}, {}, {}}, nil, 300, []int{150, 100, 50}},
+		{"max given to previous", []Pane{{}, {}, {Max: 50}}, nil, 300, []int{100, 150, 50}},
+		{"collapsed ignores min", []Pane{{Min: 50}, {}, {}}, []float32{0, 1, 1}, 300, []int{0, 150, 150}},
+		{"rest to last", []Pane{{}, {}}, nil, 301, []int{151, 150}},
+	}
+	for _, tt := range tests {
+		s := testSplit(tt.panes, tt.ratios)
+		if got := s.calcSizes(gtx, tt.avail); !equalSizes(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitMoveSash(t *testing.T) {
+	gtx := layout.Context{Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1}}
+	tests := []struct {
+		name  string
+		panes []Pane
+		delta int
+		want  []int
+	}{
+		{"free", []Pane{{}, {}}, 30, []int{130, 70}},
+		{"back", []Pane{{}, {}}, -30, []int{70, 130}},
+		{"min of second", []Pane{{}, {Min: 80}}, 30, []int{120, 80}},
+		{"max of first", []Pane{{Max: 110}, {}}, 30, []int{110, 90}},
+		{"to zero", []Pane{{}, {}}, -150, []int{0, 200}},
+		{"limits conflict", []Pane{{Min: 150}, {Min: 80}}, 30, []int{100, 100}},
+	}
+	for _, tt := range tests {
+		s := testSplit(tt.panes, nil)
+		s.moveSash(gtx, 0, []int{100, 100}, tt.delta)
+		got := make([]int, 2)
+		for i, r := range s.Ratios() {
+			got[i] = int(r*200 + 0.5)
+		}
+		if !equalSizes(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitToggle(t *testing.T) {
+	tests := []struct {
+		name   string
+		ratios []float32
+		sash   int
+		want   [][]float32
+	}{
+		{"collapse smaller and restore", []float32{0.7, 0.3}, 0,
+			[][]float32{{1, 0}, {0.7, 0.3}}},
+		{"collapse first", []float32{0.2, 0.8}, 0,
+			[][]float32{{0, 1}, {0.2, 0.8}}},
+		{"restore set ratio", []float32{0, 1}, 0,
+			[][]float32{{0.5, 0.5}, {0, 1}}},
+		{"second sash", []float32{0.2, 0.3, 0.5}, 1,
+			[][]float32{{0.2, 0, 0.8}, {0.2, 0.3, 0.5}}},
+	}
+	for _, tt := range tests {
+		s := testSplit(make([]Pane, len(tt.ratios)), tt.ratios)
+		for i, want := range tt.want {
+			s.toggle(tt.sash)
+			if got := s.Ratios(); !equalRatios(got, want) {
+				t.Errorf("%s, toggle %d: got %v, want %v", tt.name, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestSplitSetRatios(t *testing.T) {
+	tests := []struct {
+		name      string
+		ratios    []float32
+		want      []float32
+		collapsed []bool
+	}{
+		{"normalized", []float32{1, 1, 2}, []float32{0.25, 0.25, 0.5}, []bool{false, false, false}},
+		{"zero collapses", []float32{0, 1, 3}, []float32{0, 0.25, 0.75}, []bool{true, false, false}},
+		{"negative collapses", []float32{-1, 1, 1}, []float32{0, 0.5, 0.5}, []bool{true, false, false}},
+		{"short list", []float32{2, 2}, []float32{0.5, 0.5, 0}, []bool{false, false, true}},
+		{"none shown", nil, []float32{1. / 3, 1. / 3, 1. / 3}, []bool{false, false, false}},
+		{"long list", []float32{1, 1, 1, 5}, []float32{1. / 3, 1. / 3, 1. / 3}, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		s := testSplit(make([]Pane, 3), nil)
+		// Collapse a pane first, to check that SetRatios replaces the state
+		s.toggle(0)
+		s.SetRatios(tt.ratios)
+		if got := s.Ratios(); !equalRatios(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		for i, c := range tt.collapsed {
+			if (s.saved[i] > 0) != c {
+				t.Errorf("%s: pane %d collapsed is %v", tt.name, i, !c)
+			}
+		}
+	}
+}
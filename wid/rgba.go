@@ -3,9 +3,12 @@
 package wid
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
 	"math"
+	"strconv"
+	"strings"
 )
 
 // Some default colors
@@ -89,6 +92,32 @@ func ARGB(c uint32) color.NRGBA {
 	return color.NRGBA{A: uint8(c >> 24), R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c)}
 }
 
+// Hex returns the color as #RRGGBB, or #RRGGBBAA when it is not opaque
+func Hex(c color.NRGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+}
+
+var errHex = errors.New("color must be given as #RRGGBB or #RRGGBBAA")
+
+// ParseHex converts a color given as #RRGGBB or #RRGGBBAA. The # is optional.
+func ParseHex(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 8 {
+		return color.NRGBA{}, errHex
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, errHex
+	}
+	if len(s) == 6 {
+		return RGB(uint32(v)), nil
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
 // WithAlpha returns the input color with the new alpha value.
 func WithAlpha(c color.NRGBA, alpha uint8) color.NRGBA {
 	c.A = alpha
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		s    string
+		want color.NRGBA
+		ok   bool
+	}{
+		{"#45682A", color.NRGBA{R: 0x45, G: 0x68, B: 0x2A, A: 0xFF}, true},
+		{"45682a", color.NRGBA{R: 0x45, G: 0x68, B: 0x2A, A: 0xFF}, true},
+		{"#00000000", color.NRGBA{}, true},
+		{"#FF800040", color.NRGBA{R: 0xFF, G: 0x80, B: 0x00, A: 0x40}, true},
+		{"", color.NRGBA{}, false},
+		{"#", color.NRGBA{}, false},
+		{"#FFF", color.NRGBA{}, false},
+		{"#FFFFFFF", color.NRGBA{}, false},
+		{"#GG0000", color.NRGBA{}, false},
+		{"#+12345", color.NRGBA{}, false},
+		{"##123456", color.NRGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseHex(tt.s)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseHex(%q) = %v, %v, want %v, ok=%v", tt.s, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	tests := []struct {
+		c    color.NRGBA
+		want string
+	}{
+		{color.NRGBA{R: 0x45, G: 0x68, B: 0x2A, A: 0xFF}, "#45682A"},
+		{color.NRGBA{A: 0xFF}, "#000000"},
+		{color.NRGBA{R: 0xFF, G: 0x80, B: 0x01, A: 0x40}, "#FF800140"},
+		{color.NRGBA{}, "#00000000"},
+	}
+	for _, tt := range tests {
+		s := Hex(tt.c)
+		if s != tt.want {
+			t.Errorf("Hex(%v) = %q, want %q", tt.c, s, tt.want)
+		}
+		if c, err := ParseHex(s); err != nil || c != tt.c {
+			t.Errorf("ParseHex(%q) = %v, %v, want %v", s, c, err, tt.c)
+		}
+	}
+}
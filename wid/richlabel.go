@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+// Span is a part of a RichLabel with its own text style.
+type Span struct {
+	Text string
+	// Weight is the font weight. Zero means the weight of the label.
+	Weight text.Weight
+	// Style is Regular or Italic
+	Style text.Style
+	// Role gives the text color. Default is Canvas, as for labels.
+	Role UIRole
+	// Color overrides the role color when set
+	Color *color.NRGBA
+	// Size is the size relative to the label text size. Zero means 1.0
+	Size float32
+	// Mono selects a fixed width font, typically used for inline code
+	Mono      bool
+	Underline bool
+	// OnClick makes the span a hyperlink. It is called when the span is clicked.
+	OnClick func()
+}
+
+// RichLabelDef is a label with text in several styles, wrapped as one paragraph.
+type RichLabelDef struct {
+	LabelDef
+	Spans  []Span
+	clicks []gesture.Click
+	words  []richWord
+}
+
+type richWord struct {
+	span    int
+	newline bool
+	// last is set for the last word in a span
+	last bool
+	// space is the width of the trailing spaces
+	space int
+	call  op.CallOp
+	dims  D
+}
+
+// RichLabel returns a widget showing the spans as one paragraph.
+// The Label options Pads(), Middle(), Right(), Bold() and Heading() etc. can be used.
+func RichLabel(th *Theme, spans []Span, options ...Option) layout.Widget {
+	r := &RichLabelDef{Spans: spans}
+	r.th = th
+	r.TextSize = th.TextSize
+	r.Alignment = text.Start
+	r.Font = th.DefaultFont
+	r.padding = th.OutsidePadding
+	r.role = Canvas
+	r.FontSize = 1.0
+	for _, option := range options {
+		option.apply(&r.LabelDef)
+	}
+	r.padding.Bottom += th.InsidePadding.Bottom
+	r.padding.Top += th.InsidePadding.Top
+	r.padding.Left += th.InsidePadding.Left
+	r.padding.Right += th.InsidePadding.Right
+	return func(gtx C) D {
+		macro := op.Record(gtx.Ops)
+		dim := r.padding.Layout(gtx, r.Layout)
+		call := macro.Stop()
+		defer clip.Rect(image.Rectangle{Max: dim.Size}).Push(gtx.Ops).Pop()
+		if r.bgColor != nil {
+			paint.Fill(gtx.Ops, r.Bg())
+		}
+		call.Add(gtx.Ops)
+		return dim
+	}
+}
+
+// splitWords splits s into words with trailing spaces. Newlines are returned as separate words.
+func splitWords(s string) []string {
+	var words []string
+	for len(s) > 0 {
+		if s[0] == '\n' {
+			words = append(words, "\n")
+			s = s[1:]
+			continue
+		}
+		i := strings.IndexAny(s, " \n")
+		if i < 0 {
+			i = len(s)
+		}
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		words = append(words, s[:i])
+		s = s[i:]
+	}
+	return words
+}
+
+func (r *RichLabelDef) spanFont(s Span) text.Font {
+	f := r.Font
+	if s.Mono {
+		f = monoFont
+	}
+	if s.Weight != 0 {
+		f.Weight = s.Weight
+	} else if s.Mono {
+		f.Weight = r.Font.Weight
+	}
+	f.Style = s.Style
+	return f
+}
+
+// Layout draws the spans, breaking lines between words.
+func (r *RichLabelDef) Layout(gtx C) D {
+	// Spans can be changed after the label is created
+	if len(r.clicks) != len(r.Spans) {
+		r.clicks = make([]gesture.Click, len(r.Spans))
+	}
+	for i := range r.clicks {
+		for _, e := range r.clicks[i].Events(gtx) {
+			if e.Type == gesture.TypeClick && r.Spans[i].OnClick != nil {
+				r.Spans[i].OnClick()
+			}
+		}
+	}
+	// Shape all words, recording the drawing operations
+	r.words = r.words[:0]
+	c := gtx
+	c.Constraints.Min = image.Point{}
+	c.Constraints.Max.X = inf
+	for i, s := range r.Spans {
+		size := r.TextSize * unit.Sp(r.FontSize)
+		if s.Size > 0 {
+			size *= unit.Sp(s.Size)
+		}
+		font := r.spanFont(s)
+		col := r.th.Fg(s.Role)
+		if s.Color != nil {
+			col = *s.Color
+		} else if r.fgColor != nil && s.Role == Canvas {
+			col = *r.fgColor
+		}
+		if gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		macro := op.Record(gtx.Ops)
+		spaceWidth := widget.Label{MaxLines: 1}.Layout(c, r.th.Shaper, font, size, " ").Size.X
+		_ = macro.Stop()
+		for _, w := range splitWords(s.Text) {
+			if w == "\n" {
+				r.words = append(r.words, richWord{span: i, newline: true})
+				continue
+			}
+			t := strings.TrimRight(w, " ")
+			macro := op.Record(gtx.Ops)
+			paint.ColorOp{Color: col}.Add(gtx.Ops)
+			dims := widget.Label{MaxLines: 1}.Layout(c, r.th.Shaper, font, size, t)
+			space := spaceWidth * (len(w) - len(t))
+			r.words = append(r.words, richWord{span: i, call: macro.Stop(), dims: dims, space: space})
+		}
+		if n := len(r.words); n > 0 && r.words[n-1].span == i {
+			r.words[n-1].last = true
+		}
+	}
+
+	// Place words on lines, and draw each line aligned to the common baseline
+	maxWidth := gtx.Constraints.Max.X
+	width, y := 0, 0
+	for start := 0; start < len(r.words); {
+		end, x, ascent, descent := start, 0, 0, 0
+		for ; end < len(r.words); end++ {
+			w := r.words[end]
+			if w.newline {
+				end++
+				break
+			}
+			if x > 0 && x+w.dims.Size.X > maxWidth {
+				break
+			}
+			x += w.dims.Size.X + w.space
+			ascent = Max(ascent, w.dims.Size.Y-w.dims.Baseline)
+			descent = Max(descent, w.dims.Baseline)
+		}
+		if ascent+descent == 0 {
+			// Empty line, use the height of the label text size
+			ascent = gtx.Sp(r.TextSize * unit.Sp(r.FontSize))
+		}
+		// Trailing spaces at the end of a line are not counted for alignment
+		if end > start {
+			x -= r.words[end-1].space
+		}
+		dx := 0
+		if r.Alignment == text.Middle {
+			dx = (maxWidth - x) / 2
+		} else if r.Alignment == text.End {
+			dx = maxWidth - x
+		}
+		for _, w := range r.words[start:end] {
+			if w.newline {
+				continue
+			}
+			pos := image.Pt(dx, y+ascent-(w.dims.Size.Y-w.dims.Baseline))
+			r.drawWord(gtx, w, pos)
+			dx += w.dims.Size.X + w.space
+		}
+		width = Max(width, x)
+		y += ascent + descent
+		start = end
+	}
+	if r.Alignment != text.Start {
+		width = maxWidth
+	}
+	return D{Size: image.Pt(Min(width, maxWidth), y)}
+}
+
+func (r *RichLabelDef) drawWord(gtx C, w richWord, pos image.Point) {
+	defer op.Offset(pos).Push(gtx.Ops).Pop()
+	w.call.Add(gtx.Ops)
+	s := r.Spans[w.span]
+	size := w.dims.Size
+	if !w.last {
+		// Spaces between words in the same span are included in underline and click area
+		size.X += w.space
+	}
+	if s.Underline || s.OnClick != nil && r.clicks[w.span].Hovered() {
+		thickness := Max(1, size.Y/16)
+		baseline := size.Y - w.dims.Baseline
+		rect := image.Rect(0, baseline+thickness, size.X, baseline+2*thickness)
+		col := r.th.Fg(s.Role)
+		if s.Color != nil {
+			col = *s.Color
+		}
+		paint.FillShape(gtx.Ops, col, clip.Rect(rect).Op())
+	}
+	if s.OnClick != nil {
+		defer clip.Rect(image.Rectangle{Max: size}).Push(gtx.Ops).Pop()
+		r.clicks[w.span].Add(gtx.Ops)
+		pointer.CursorPointer.Add(gtx.Ops)
+	}
+}
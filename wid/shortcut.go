@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"runtime"
+	"strings"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+)
+
+// Priority decides if a shortcut is handled before or after the focused widget
+type Priority uint8
+
+const (
+	// AfterFocus shortcuts are only triggered when the focused widget does not use the key.
+	AfterFocus Priority = iota
+	// BeforeFocus shortcuts take the key even if the focused widget would use it.
+	BeforeFocus
+)
+
+// Shortcut is a key combination bound to a handler for the whole form.
+type Shortcut struct {
+	// Keys is the key combination, like "Short-S" or "Short-Shift-P". See key.Set.
+	Keys key.Set
+	// Name is the description shown in help overlays
+	Name     string
+	Priority Priority
+	do       func()
+	enabled  func() bool
+}
+
+// ShortcutOption is options specific to shortcuts
+type ShortcutOption func(*Shortcut)
+
+// shortcuts is the registry used by RunWithContext, guarded by GuiLock
+var (
+	shortcuts   []*Shortcut
+	shortcutTag struct{}
+)
+
+// AddShortcut registers a handler for a key combination, dispatched by RunWithContext.
+// The options When() and Override() can be used.
+func AddShortcut(keys key.Set, name string, do func(), options ...Option) *Shortcut {
+	s := &Shortcut{Keys: keys, Name: name, do: do}
+	for _, option := range options {
+		option.apply(s)
+	}
+	GuiLock.Lock()
+	shortcuts = append(shortcuts, s)
+	GuiLock.Unlock()
+	return s
+}
+
+// RemoveShortcut removes a shortcut from the registry
+func RemoveShortcut(s *Shortcut) {
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	for i := range shortcuts {
+		if shortcuts[i] == s {
+			shortcuts = append(shortcuts[:i], shortcuts[i+1:]...)
+			return
+		}
+	}
+}
+
+// Shortcuts returns all registered shortcuts, in the order they were added.
+func Shortcuts() []Shortcut {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	list := make([]Shortcut, len(shortcuts))
+	for i, s := range shortcuts {
+		list[i] = *s
+	}
+	return list
+}
+
+func (o ShortcutOption) apply(cfg interface{}) {
+	if s, ok := cfg.(*Shortcut); ok {
+		o(s)
+	}
+}
+
+// When sets a function that enables the shortcut. Disabled shortcuts let the key pass to the widgets.
+func When(enabled func() bool) ShortcutOption {
+	return func(s *Shortcut) {
+		s.enabled = enabled
+	}
+}
+
+// Override gives the shortcut priority over the focused widget, like Ctrl+Z for an application undo.
+func Override() ShortcutOption {
+	return func(s *Shortcut) {
+		s.Priority = BeforeFocus
+	}
+}
+
+// Enabled returns true if the shortcut has no When() function, or if it returns true.
+func (s Shortcut) Enabled() bool {
+	return s.enabled == nil || s.enabled()
+}
+
+// Text returns the key combination in a readable form, like "Ctrl+Shift+P"
+func (s Shortcut) Text() string {
+	return KeyText(s.Keys)
+}
+
+// KeyText converts the first combination in a key set to a readable form, like "Ctrl+S".
+// The Short modifier is shown as ⌘ on macOS.
+func KeyText(k key.Set) string {
+	chord, _, _ := strings.Cut(string(k), "|")
+	var parts []string
+	if i := strings.LastIndex(chord, "-"); i > 0 {
+		for _, m := range strings.Split(chord[:i], "-") {
+			if strings.HasPrefix(m, "(") {
+				// Optional modifiers are not shown
+				continue
+			}
+			switch m {
+			case "Short":
+				m = "Ctrl"
+				if runtime.GOOS == "darwin" {
+					m = "⌘"
+				}
+			case "ShortAlt":
+				m = "Alt"
+				if runtime.GOOS == "darwin" {
+					m = "⌥"
+				}
+			}
+			parts = append(parts, m)
+		}
+		chord = chord[i+1:]
+	}
+	return strings.Join(append(parts, chord), "+")
+}
+
+// dispatchShortcut calls the handler of the first enabled shortcut matching e.
+// Only shortcuts with BeforeFocus priority are used when before is set.
+// It returns true if the key was used by a shortcut.
+func dispatchShortcut(e key.Event, before bool) bool {
+	GuiLock.RLock()
+	list := append([]*Shortcut(nil), shortcuts...)
+	GuiLock.RUnlock()
+	for _, s := range list {
+		if before && s.Priority != BeforeFocus {
+			continue
+		}
+		if s.Keys.Contains(e.Name, e.Modifiers) && s.Enabled() {
+			if e.State == key.Press && s.do != nil {
+				s.do()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// handleShortcuts dispatches keys not used by the focused widget, and registers
+// the keys of all enabled shortcuts for the next frame.
+func handleShortcuts(gtx C) {
+	for _, e := range gtx.Events(&shortcutTag) {
+		if e, ok := e.(key.Event); ok {
+			dispatchShortcut(e, false)
+		}
+	}
+	if gtx.Queue == nil {
+		return
+	}
+	var keys []string
+	for _, s := range Shortcuts() {
+		if s.Enabled() {
+			keys = append(keys, string(s.Keys))
+		}
+	}
+	if len(keys) > 0 {
+		key.InputOp{Tag: &shortcutTag, Keys: key.Set(strings.Join(keys, "|"))}.Add(gtx.Ops)
+	}
+}
+
+// shortcutQueue filters the events of the form, so that keys used by shortcuts with
+// BeforeFocus priority never reach the focused widget.
+type shortcutQueue struct {
+	event.Queue
+}
+
+func (q shortcutQueue) Events(t event.Tag) []event.Event {
+	events := q.Queue.Events(t)
+	for i, e := range events {
+		if ke, ok := e.(key.Event); ok && dispatchShortcut(ke, true) {
+			// Copy the remaining events that are not used by shortcuts
+			filtered := append([]event.Event(nil), events[:i]...)
+			for _, e := range events[i+1:] {
+				if ke, ok := e.(key.Event); !ok || !dispatchShortcut(ke, true) {
+					filtered = append(filtered, e)
+				}
+			}
+			return filtered
+		}
+	}
+	return events
+}
@@ -4,6 +4,8 @@ package wid
 
 import (
 	"image"
+	"math"
+	"strconv"
 
 	"gioui.org/io/key"
 
@@ -16,6 +18,7 @@ import (
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
+	"gioui.org/widget"
 )
 
 // SliderStyle is the parameters for a slider
@@ -25,38 +28,74 @@ type SliderStyle struct {
 	hovered  bool
 	axis     layout.Axis
 	drag     gesture.Drag
-	pos      float32 // position normalized to [0, 1]
-	length   float32
+	dragging bool
 	min, max float32
 	Value    *float32
-	keyTag   struct{}
+	// High is the upper value of a range slider, and nil when there is only one thumb
+	High *float32
+	// active is the thumb moved by the keys, 0 for Value and 1 for High
+	active     int
+	step       float32
+	ticks      int
+	tickLabels bool
+	bubble     bool
+	log        bool
+	format     func(v float32) string
+	keyTag     struct{}
+	request    focusRequest
 }
 
+// SliderOption is options specific to sliders
+type SliderOption func(*SliderStyle)
+
 // Slider is for selecting a value in a range.
 func Slider(th *Theme, value *float32, minV, maxV float32, options ...Option) layout.Widget {
-	s := SliderStyle{
+	return newSlider(th, value, nil, minV, maxV, options)
+}
+
+// RangeSlider is for selecting the range lo..hi inside minV..maxV, with one thumb for each end.
+// The arrow keys move the thumb last dragged, and Space selects the other thumb.
+func RangeSlider(th *Theme, lo, hi *float32, minV, maxV float32, options ...Option) layout.Widget {
+	return newSlider(th, lo, hi, minV, maxV, options)
+}
+
+func newSlider(th *Theme, lo, hi *float32, minV, maxV float32, options []Option) layout.Widget {
+	s := &SliderStyle{
 		min:   minV,
 		max:   maxV,
-		Value: value,
+		Value: lo,
+		High:  hi,
 	}
 	s.th = th
 	s.width = unit.Dp(99999)
-	s.Apply(options...)
+	for _, option := range options {
+		option.apply(s)
+	}
+	// A logarithmic scale needs positive values
+	s.log = s.log && s.min > 0 && s.max > s.min
 
 	return func(gtx C) D {
 		s.handleKeys(gtx)
 		m := op.Record(gtx.Ops)
 		dims := s.Layout(gtx)
 		c := m.Stop()
+		if s.focused {
+			paintFocusRing(gtx, s.th, image.Rectangle{Max: dims.Size}, Min(dims.Size.X, dims.Size.Y)/2)
+		}
 		defer clip.Rect{Max: dims.Size}.Push(gtx.Ops).Pop()
 		disabled := gtx.Queue == nil
 		keys := key.Set("")
 		if !disabled {
-			keys = "(Ctrl)-[→,↓,←,↑,0,9,↓]"
+			keys = "(Ctrl)-[→,↓,←,↑,0,9]|⇞|⇟|⇱|⇲"
+			if s.High != nil {
+				keys += "|Space"
+			}
 			if !s.focused {
 				keys = ""
 			}
 			key.InputOp{Tag: &s.keyTag, Keys: keys}.Add(gtx.Ops)
+			s.request.apply(gtx, &s.keyTag, s.focused)
+			registerFocus(gtx, s, s.tabIndex)
 		} else {
 			s.focused = false
 		}
@@ -65,6 +104,71 @@ func Slider(th *Theme, value *float32, minV, maxV float32, options ...Option) la
 	}
 }
 
+func (o SliderOption) apply(cfg interface{}) {
+	if s, ok := cfg.(*SliderStyle); ok {
+		o(s)
+	}
+}
+
+// Vertical makes the slider vertical, with the maximum at the top
+func Vertical() SliderOption {
+	return func(s *SliderStyle) {
+		s.axis = layout.Vertical
+	}
+}
+
+// Step snaps the values to min + n*step
+func Step(step float32) SliderOption {
+	return func(s *SliderStyle) {
+		s.step = step
+	}
+}
+
+// Ticks draws n+1 tick marks at even distances, with the values below them when labels is set.
+func Ticks(n int, labels bool) SliderOption {
+	return func(s *SliderStyle) {
+		s.ticks = n
+		s.tickLabels = labels
+	}
+}
+
+// ValueBubble shows the value above the thumb while it is dragged
+func ValueBubble() SliderOption {
+	return func(s *SliderStyle) {
+		s.bubble = true
+	}
+}
+
+// ValueFormat sets the function formatting the values in tick labels and the value bubble
+func ValueFormat(f func(v float32) string) SliderOption {
+	return func(s *SliderStyle) {
+		s.format = f
+	}
+}
+
+// LogScale uses a logarithmic scale, as for frequency and gain controls.
+// The minimum value must be above zero.
+func LogScale() SliderOption {
+	return func(s *SliderStyle) {
+		s.log = true
+	}
+}
+
+// Focus moves the keyboard focus to the slider when it is drawn next time
+func (s *SliderStyle) Focus() {
+	s.request.set(focusRequested)
+}
+
+// Blur removes the keyboard focus from the slider
+func (s *SliderStyle) Blur() {
+	s.request.set(blurRequested)
+}
+
+// Focused returns true if the slider has the keyboard focus
+func (s *SliderStyle) Focused() bool {
+	return s.focused
+}
+
 func (s *SliderStyle) handleKeys(gtx C) {
 	for _, ev := range gtx.Events(&s.keyTag) {
 		switch ke := ev.(type) {
@@ -72,136 +176,287 @@ func (s *SliderStyle) handleKeys(gtx C) {
 			s.focused = ke.Focus
 		case key.Event:
 			if ke.State == key.Press {
-				d := float32(0.01)
-				if ke.Modifiers.Contain(key.ModCtrl) {
-					d = 0.1
-				}
+				big := ke.Modifiers.Contain(key.ModCtrl)
 				switch ke.Name {
-				case "0":
-					s.pos = 0
-				case "9":
-					s.pos = 1.0
-				case key.NameUpArrow, key.NameLeftArrow:
-					s.pos -= d
-				case key.NameDownArrow, key.NameRightArrow:
-					s.pos += d
+				case "0", key.NameHome:
+					s.setValue(s.active, s.min)
+				case "9", key.NameEnd:
+					s.setValue(s.active, s.max)
+				case key.NameUpArrow, key.NameRightArrow:
+					s.move(1, big)
+				case key.NameDownArrow, key.NameLeftArrow:
+					s.move(-1, big)
+				case key.NamePageUp:
+					s.move(1, true)
+				case key.NamePageDown:
+					s.move(-1, true)
+				case key.NameSpace:
+					s.active = 1 - s.active
 				}
-				s.setValue()
 			}
 		}
 	}
 }
 
+// move changes the active thumb by one step, or by 1% of the length
+// when there are no steps. Big moves are 10 times larger.
+func (s *SliderStyle) move(dir float32, big bool) {
+	n := float32(1)
+	if big {
+		n = 10
+	}
+	v := s.value(s.active)
+	if s.step > 0 {
+		s.setValue(s.active, v+dir*n*s.step)
+	} else {
+		s.setValue(s.active, s.valueAt(s.posOf(v)+dir*n*0.01))
+	}
+}
+
+// ptr returns the value of thumb i
+func (s *SliderStyle) ptr(i int) *float32 {
+	if i == 1 && s.High != nil {
+		return s.High
+	}
+	return s.Value
+}
+
+func (s *SliderStyle) value(i int) float32 {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return *s.ptr(i)
+}
+
+// setValue stores v in thumb i, snapped to the steps and limited by the other thumb
+func (s *SliderStyle) setValue(i int, v float32) {
+	if s.step > 0 {
+		v = s.min + float32(math.Round(float64((v-s.min)/s.step)))*s.step
+	}
+	v = Clamp(v, s.min, s.max)
+	if s.High != nil && i == 0 {
+		v = Min(v, s.value(1))
+	} else if s.High != nil {
+		v = Max(v, s.value(0))
+	}
+	GuiLock.Lock()
+	changed := *s.ptr(i) != v
+	*s.ptr(i) = v
+	GuiLock.Unlock()
+	if changed && s.onUserChange != nil {
+		s.onUserChange()
+	}
+}
+
+// posOf returns the position of v, normalized to [0, 1]
+func (s *SliderStyle) posOf(v float32) float32 {
+	if s.max == s.min {
+		return 0
+	}
+	if s.log {
+		return Clamp(float32(math.Log(float64(v/s.min))/math.Log(float64(s.max/s.min))), 0, 1)
+	}
+	return Clamp((v-s.min)/(s.max-s.min), 0, 1)
+}
+
+// valueAt returns the value at the normalized position p
+func (s *SliderStyle) valueAt(p float32) float32 {
+	p = Clamp(p, 0, 1)
+	if s.log {
+		return s.min * float32(math.Exp(float64(p)*math.Log(float64(s.max/s.min))))
+	}
+	return s.min + p*(s.max-s.min)
+}
+
+// text formats v for the tick labels and the value bubble
+func (s *SliderStyle) text(v float32) string {
+	if s.format != nil {
+		return s.format(v)
+	}
+	if s.step > 0 {
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	// Three significant digits on a log scale, otherwise the precision of 1% of the range
+	d := 2 - math.Floor(math.Log10(float64(s.max-s.min)))
+	if s.log {
+		d = 2 - math.Floor(math.Log10(math.Abs(float64(v))))
+	}
+	return strconv.FormatFloat(float64(v), 'f', Max(0, int(d)), 32)
+}
+
 // Layout will draw the slider
 func (s *SliderStyle) Layout(gtx C) D {
+	th := s.th
 	w := gtx.Dp(s.width)
 	if w < gtx.Constraints.Min.X {
 		gtx.Constraints.Min.X = w
 	}
-	thumbRadius := gtx.Sp(s.th.TextSize * 0.5)
-	trackWidth := gtx.Sp(s.th.TextSize * 0.5)
+	thumbRadius := gtx.Sp(th.TextSize * 0.5)
+	trackWidth := gtx.Sp(th.TextSize * 0.5)
+	labelSize := th.TextSize * 0.8
+	tickLength := thumbRadius / 2
 
+	// The length along the axis. Vertical sliders get a default height.
+	length := s.axis.Convert(gtx.Constraints.Min).X
+	if s.axis == layout.Vertical && length == 0 {
+		length = Min(gtx.Sp(th.TextSize*10), gtx.Constraints.Max.Y)
+	}
 	// Keep a minimum length so that the track is always visible.
-	minLength := thumbRadius + 3*thumbRadius + thumbRadius
+	length = Max(length, 5*thumbRadius)
+	travel := length - 2*thumbRadius
 	// Try to expand to finger size, but only if the constraints allow for it.
-	touchSizePx := Min(gtx.Dp(s.th.FingerSize), s.axis.Convert(gtx.Constraints.Max).Y)
-	sizeMain := Max(s.axis.Convert(gtx.Constraints.Min).X, minLength)
+	touchSizePx := Min(gtx.Dp(th.FingerSize), s.axis.Convert(gtx.Constraints.Max).Y)
 	sizeCross := Max(2*thumbRadius, touchSizePx)
-	// size := s.axis.Convert(image.Pt(sizeMain, sizeCross))
+	center := sizeCross / 2
+	labelWidth, labelHeight := gtx.Sp(labelSize*5), gtx.Sp(labelSize*1.4)
+	extra := 0
+	if s.ticks > 0 {
+		extra = tickLength
+		if s.tickLabels && s.axis == layout.Horizontal {
+			extra += labelHeight
+		} else if s.tickLabels {
+			extra += labelWidth
+		}
+	}
+	size := s.axis.Convert(image.Pt(length, sizeCross+extra))
 
-	o := s.axis.Convert(image.Pt(thumbRadius, 0))
-	op.Offset(o).Add(gtx.Ops)
-	gtx.Constraints.Min = s.axis.Convert(image.Pt(sizeMain-2*thumbRadius, sizeCross))
+	// along returns the coordinate along the axis for the normalized position p
+	along := func(p float32) int {
+		if s.axis == layout.Vertical {
+			return length - thumbRadius - int(p*float32(travel))
+		}
+		return thumbRadius + int(p*float32(travel))
+	}
 
 	disabled := gtx.Queue == nil
 	semantic.DisabledOp(disabled).Add(gtx.Ops)
 	semantic.Switch.Add(gtx.Ops)
 
-	size := gtx.Constraints.Min
-	s.length = float32(s.axis.Convert(size).X)
-
-	var de *pointer.Event
 	for _, e := range s.drag.Events(gtx.Metric, gtx, gesture.Axis(s.axis)) {
 		switch e.Type {
 		case pointer.Press, pointer.Drag:
 			key.FocusOp{Tag: &s.keyTag}.Add(gtx.Ops)
-			de = &e
-		case pointer.Leave, pointer.Cancel:
-			s.hovered = false
-		case pointer.Enter:
-			s.hovered = true
+			xy := e.Position.X - float32(thumbRadius)
+			if s.axis == layout.Vertical {
+				xy = float32(length-thumbRadius) - e.Position.Y
+			}
+			p := xy / float32(Max(1, travel))
+			if e.Type == pointer.Press {
+				s.dragging = true
+				if s.High != nil {
+					// Take the nearest thumb, or the upper one when they are at the same place
+					lo, hi := s.posOf(s.value(0)), s.posOf(s.value(1))
+					s.active = 0
+					if d := Abs(p-hi) - Abs(p-lo); d < 0 || d == 0 && p > hi {
+						s.active = 1
+					}
+				}
+			}
+			s.setValue(s.active, s.valueAt(p))
+		case pointer.Release, pointer.Cancel:
+			s.dragging = false
 		}
 	}
-	if de != nil {
-		xy := de.Position.X
-		if s.axis == layout.Vertical {
-			xy = de.Position.Y
+	for _, e := range gtx.Events(&s.hovered) {
+		if e, ok := e.(pointer.Event); ok {
+			switch e.Type {
+			case pointer.Enter:
+				s.hovered = true
+			case pointer.Leave, pointer.Cancel:
+				s.hovered = false
+			}
 		}
-		s.pos = xy / (float32(thumbRadius) + s.length)
-	}
-
-	s.setValue()
-
-	margin := s.axis.Convert(image.Pt(thumbRadius, 0))
-	rect := image.Rectangle{
-		Min: margin.Mul(-1),
-		Max: size.Add(margin),
 	}
-	defer clip.Rect(rect).Push(gtx.Ops).Pop()
-	s.drag.Add(gtx.Ops)
-
-	gtx.Constraints.Min = gtx.Constraints.Min.Add(s.axis.Convert(image.Pt(0, sizeCross)))
-	thumbPos := thumbRadius + int(s.pos*(float32(sizeMain-thumbRadius*5)))
 
-	color := WithAlpha(s.th.Fg(Canvas), 175)
-	if gtx.Queue == nil {
+	color := WithAlpha(th.Fg(Canvas), 175)
+	if disabled {
 		color = Disabled(color)
 	}
-
-	// Draw track before thumb.
-	track := image.Rectangle{
-		Min: s.axis.Convert(image.Pt(thumbRadius, sizeCross/2-trackWidth/2)),
-		Max: s.axis.Convert(image.Pt(thumbPos, sizeCross/2+trackWidth/2)),
+	// track returns the rectangle of the track between the normalized positions a and b
+	track := func(a, b float32) clip.Op {
+		x0, x1 := along(a), along(b)
+		r := image.Rectangle{
+			Min: s.axis.Convert(image.Pt(Min(x0, x1), center-trackWidth/2)),
+			Max: s.axis.Convert(image.Pt(Max(x0, x1), center+trackWidth/2)),
+		}
+		return clip.UniformRRect(r, trackWidth/2).Op(gtx.Ops)
+	}
+	pos := []float32{s.posOf(s.value(0))}
+	start := float32(0)
+	if s.High != nil {
+		pos = append(pos, s.posOf(s.value(1)))
+		start = pos[0]
 	}
-	paint.FillShape(gtx.Ops, color, clip.RRect{
-		Rect: image.Rect(track.Min.X, track.Min.Y, track.Max.X, track.Max.Y),
-		SW:   5, NW: 5, NE: 5, SE: 5,
-	}.Op(gtx.Ops))
+	// Draw the whole track, and the selected part on top of it
+	paint.FillShape(gtx.Ops, WithAlpha(color, 80), track(0, 1))
+	paint.FillShape(gtx.Ops, color, track(start, pos[len(pos)-1]))
 
-	// Draw track after thumb.
-	track = image.Rectangle{
-		Min: s.axis.Convert(image.Pt(thumbPos, s.axis.Convert(track.Min).Y)),
-		Max: s.axis.Convert(image.Pt(sizeMain-2*thumbRadius, s.axis.Convert(track.Max).Y)),
+	for i := 0; s.ticks > 0 && i <= s.ticks; i++ {
+		p := float32(i) / float32(s.ticks)
+		a := along(p)
+		tw := Max(1, gtx.Dp(1))
+		paint.FillShape(gtx.Ops, WithAlpha(color, 120), clip.Rect{
+			Min: s.axis.Convert(image.Pt(a-tw/2, sizeCross)),
+			Max: s.axis.Convert(image.Pt(a-tw/2+tw, sizeCross+tickLength)),
+		}.Op())
+		if !s.tickLabels {
+			continue
+		}
+		var r image.Rectangle
+		if s.axis == layout.Horizontal {
+			x := Clamp(a-labelWidth/2, 0, Max(0, length-labelWidth))
+			r = image.Rect(x, sizeCross+tickLength, x+labelWidth, sizeCross+tickLength+labelHeight)
+		} else {
+			r = image.Rect(sizeCross+tickLength, a-labelHeight/2, sizeCross+tickLength+labelWidth, a+labelHeight/2)
+		}
+		centeredText(gtx, th, r, s.text(s.valueAt(p)), color, labelSize)
 	}
-	paint.FillShape(gtx.Ops, WithAlpha(color, 80), clip.RRect{
-		Rect: image.Rect(track.Min.X, track.Min.Y, track.Max.X, track.Max.Y),
-		SW:   5, NW: 5, NE: 5, SE: 5,
-	}.Op(gtx.Ops))
 
-	// Draw thumb.
-	pt := s.axis.Convert(image.Pt(thumbPos, sizeCross/2))
-	if s.hovered || s.focused {
-		r := int(float32(thumbRadius) * 1.35)
-		ul := image.Pt(pt.X-r, pt.Y-r)
-		lr := image.Pt(pt.X+r, pt.Y+r)
-		paint.FillShape(gtx.Ops, MulAlpha(s.th.Fg(Canvas), 88), clip.Ellipse{Min: ul, Max: lr}.Op(gtx.Ops))
+	// Draw the thumbs, with a halo on the active thumb when hovered or focused
+	for i, p := range pos {
+		pt := s.axis.Convert(image.Pt(along(p), center))
+		if (s.hovered || s.focused) && (len(pos) == 1 || i == s.active) {
+			r := int(float32(thumbRadius) * 1.35)
+			paint.FillShape(gtx.Ops, MulAlpha(th.Fg(Canvas), 88), clip.Ellipse{Min: pt.Sub(image.Pt(r, r)), Max: pt.Add(image.Pt(r, r))}.Op(gtx.Ops))
+		}
+		r := thumbRadius
+		paint.FillShape(gtx.Ops, ColDisabled(th.Fg(Canvas), disabled), clip.Ellipse{Min: pt.Sub(image.Pt(r, r)), Max: pt.Add(image.Pt(r, r))}.Op(gtx.Ops))
+	}
+	if s.bubble && s.dragging {
+		s.drawBubble(gtx, s.axis.Convert(image.Pt(along(pos[Min(s.active, len(pos)-1)]), center)), thumbRadius)
 	}
-	r := thumbRadius
-	ul := image.Pt(pt.X-r, pt.Y-r)
-	lr := image.Pt(pt.X+r, pt.Y+r)
-	paint.FillShape(gtx.Ops, s.th.Fg(Canvas), clip.Ellipse{ul, lr}.Op(gtx.Ops))
+
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	s.drag.Add(gtx.Ops)
+	pass := pointer.PassOp{}.Push(gtx.Ops)
+	pointer.InputOp{Tag: &s.hovered, Types: pointer.Enter | pointer.Leave}.Add(gtx.Ops)
+	pass.Pop()
 
 	return layout.Dimensions{Size: size}
 }
 
-func (s *SliderStyle) setValue() {
-	if s.pos < 0 {
-		s.pos = 0
-	}
-	if s.pos > 1.0 {
-		s.pos = 1.0
+// drawBubble shows the value of the active thumb at pt, above a horizontal slider
+// or to the left of a vertical slider. It is drawn on top of the other widgets.
+func (s *SliderStyle) drawBubble(gtx C, pt image.Point, thumbRadius int) {
+	th := s.th
+	macro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: th.Fg(Primary)}.Add(gtx.Ops)
+	c := gtx
+	c.Constraints = layout.Constraints{Max: image.Pt(inf, inf)}
+	dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize*0.9, s.text(s.value(s.active)))
+	text := macro.Stop()
+
+	pad := gtx.Dp(th.InsidePadding.Left)
+	size := dims.Size.Add(image.Pt(2*pad, pad))
+	gap := thumbRadius * 3 / 2
+	pos := image.Pt(pt.X-size.X/2, pt.Y-gap-size.Y)
+	if s.axis == layout.Vertical {
+		pos = image.Pt(pt.X-gap-size.X, pt.Y-size.Y/2)
 	}
-	GuiLock.Lock()
-	*s.Value = s.pos*(s.max-s.min) + s.min
-	GuiLock.Unlock()
+	macro = op.Record(gtx.Ops)
+	o := op.Offset(pos).Push(gtx.Ops)
+	paint.FillShape(gtx.Ops, th.Bg(Primary), clip.UniformRRect(image.Rectangle{Max: size}, size.Y/2).Op(gtx.Ops))
+	op.Offset(image.Pt(pad, pad/2)).Add(gtx.Ops)
+	text.Add(gtx.Ops)
+	o.Pop()
+	op.Defer(gtx.Ops, macro.Stop())
 }
@@ -10,8 +10,6 @@ import (
 
 	"gioui.org/io/semantic"
 
-	"gioui.org/widget"
-
 	"gioui.org/layout"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
@@ -21,7 +19,7 @@ import (
 // SwitchDef is the parameters for a slider
 type SwitchDef struct {
 	Base
-	sw            widget.Bool
+	Clickable
 	StatePtr      *bool
 	trackColorOn  color.NRGBA
 	trackColorOff color.NRGBA
@@ -34,6 +32,8 @@ type SwitchDef struct {
 	trackLength   unit.Dp
 	btnOnSize     unit.Dp
 	btnOffSize    unit.Dp
+	// pos is the position of the thumb, from 0 (off) to 1 (on)
+	pos Animator[float32]
 }
 
 // Switch returns a widget for a switch
@@ -67,19 +67,18 @@ func Switch(th *Theme, statePtr *bool, options ...Option) func(gtx C) D {
 
 // Layout updates the switch and displays it.
 func (s *SwitchDef) Layout(gtx C) D {
-
-	if s.sw.Changed() {
+	s.HandleEvents(gtx)
+	for s.Clicked() {
 		GuiLock.Lock()
-		*s.StatePtr = s.sw.Value
+		*s.StatePtr = !*s.StatePtr
 		if s.onUserChange != nil {
 			s.onUserChange()
 		}
 		GuiLock.Unlock()
-	} else {
-		GuiLock.RLock()
-		s.sw.Value = *s.StatePtr
-		GuiLock.RUnlock()
 	}
+	GuiLock.RLock()
+	value := *s.StatePtr
+	GuiLock.RUnlock()
 
 	width := gtx.Dp(s.trackLength)
 	height := gtx.Dp(s.trackWidth)
@@ -88,51 +87,57 @@ func (s *SwitchDef) Layout(gtx C) D {
 	stroke := float32(gtx.Dp(s.trackStroke))
 	r := gtx.Dp(s.trackWidth / 4)
 	trackRect := image.Rect(0, 0, width, height)
-	if s.sw.Focused() && s.sw.Hovered() {
+	if s.Focused() && s.Hovered() {
 		s.hoverShadow = MulAlpha(s.th.Bg(Primary), 120)
-	} else if s.sw.Focused() {
+	} else if s.Focused() {
 		s.hoverShadow = MulAlpha(s.th.Bg(Primary), 90)
-	} else if s.sw.Hovered() {
+	} else if s.Hovered() {
 		s.hoverShadow = MulAlpha(s.th.Bg(Primary), 60)
 	} else {
 		s.hoverShadow = MulAlpha(s.th.Bg(Primary), 0)
 	}
 
-	if s.sw.Value {
-		// Draw track in on position, filled rounded
-		paint.FillShape(gtx.Ops, s.trackColorOn, clip.UniformRRect(trackRect, height/2).Op(gtx.Ops))
-		// Draw thumb,
-		paint.FillShape(gtx.Ops, s.thumbColorOn,
-			clip.Ellipse{image.Point{X: 3 * r, Y: r / 2}, image.Point{X: onSize + 3*r, Y: onSize + r/2}}.Op(gtx.Ops))
-		// Draw hover/focus shade.
-		paint.FillShape(gtx.Ops, s.hoverShadow,
-			clip.Ellipse{Min: image.Point{X: 2 * r, Y: -r / 2}, Max: image.Point{X: 7 * r, Y: 9 * r / 2}}.Op(gtx.Ops))
-		// TODO: Draw icon
-	} else {
-		// First draw track in OFF position, outlined
-		paint.FillShape(gtx.Ops, s.trackColorOff, clip.UniformRRect(trackRect, height/2).Op(gtx.Ops))
-		paint.FillShape(gtx.Ops, s.trackOutline,
+	if s.Focused() {
+		paintFocusRing(gtx, s.th, trackRect, height/2)
+	}
+	var target float32
+	if value {
+		target = 1
+	}
+	// The thumb slides and grows, while the colors fade between the off and on styles
+	p := s.pos.Animate(gtx, target)
+	pt := func(off, on image.Point) image.Point {
+		return Lerp(off, on, p)
+	}
+	// Draw track, filled when on and outlined when off
+	paint.FillShape(gtx.Ops, Lerp(s.trackColorOff, s.trackColorOn, p), clip.UniformRRect(trackRect, height/2).Op(gtx.Ops))
+	if p < 1 {
+		paint.FillShape(gtx.Ops, MulAlpha(s.trackOutline, uint8(255*Clamp(1-p, 0, 1))),
 			clip.Stroke{Path: clip.UniformRRect(trackRect, height/2).Path(gtx.Ops), Width: stroke}.Op())
-		// Draw thumb
-		paint.FillShape(gtx.Ops, s.thumbColorOff,
-			clip.Ellipse{image.Point{X: +r, Y: +r}, image.Point{X: offSize + r, Y: offSize + r}}.Op(gtx.Ops))
-		// Draw hover/focus shade.
-		paint.FillShape(gtx.Ops, s.hoverShadow,
-			clip.Ellipse{Min: image.Point{X: -r / 2, Y: -r / 2}, Max: image.Point{X: 9 * r / 2, Y: 9 * r / 2}}.Op(gtx.Ops))
-		// TODO: Draw icon
 	}
+	// Draw thumb
+	paint.FillShape(gtx.Ops, Lerp(s.thumbColorOff, s.thumbColorOn, p),
+		clip.Ellipse{
+			Min: pt(image.Pt(r, r), image.Pt(3*r, r/2)),
+			Max: pt(image.Pt(offSize+r, offSize+r), image.Pt(onSize+3*r, onSize+r/2))}.Op(gtx.Ops))
+	// Draw hover/focus shade.
+	paint.FillShape(gtx.Ops, s.hoverShadow,
+		clip.Ellipse{
+			Min: pt(image.Pt(-r/2, -r/2), image.Pt(2*r, -r/2)),
+			Max: pt(image.Pt(9*r/2, 9*r/2), image.Pt(7*r, 9*r/2))}.Op(gtx.Ops))
+	// TODO: Draw icon
 	// Set up click area.
 	defer op.Offset(image.Point{-10, -10}).Push(gtx.Ops).Pop()
 	sz := image.Pt(width+20, height+20)
 	clickRect := image.Rect(0, 0, width+20, height+20)
 	defer clip.UniformRRect(clickRect, height/2).Push(gtx.Ops).Pop()
-	s.sw.Layout(gtx, func(gtx C) D {
-		if s.description != "" {
-			semantic.DescriptionOp(s.description).Add(gtx.Ops)
-		}
-		semantic.Switch.Add(gtx.Ops)
-		return layout.Dimensions{Size: sz}
-	})
+	if s.description != "" {
+		semantic.DescriptionOp(s.description).Add(gtx.Ops)
+	}
+	semantic.Switch.Add(gtx.Ops)
+	semantic.SelectedOp(value).Add(gtx.Ops)
+	s.SetupEventHandlers(gtx, sz)
+	registerFocus(gtx, s, s.tabIndex)
 	// pointer.CursorPointer.Add(gtx.Ops)
 
 	return layout.Dimensions{Size: image.Point{X: width, Y: height}}
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"time"
+
+	"gioui.org/gesture"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// TabPage is one page in a Tabs container.
+type TabPage struct {
+	// Title is the tab text. It can be empty for icon-only tabs.
+	Title string
+	// Icon is shown in front of the title when given.
+	Icon *Icon
+	// Widget is the page content, shown when the tab is selected.
+	Widget layout.Widget
+	// OnClose adds a close button to the tab. It is called when the button is clicked,
+	// and the tab is removed if it returns true.
+	OnClose func() bool
+}
+
+// TabsDef is a tab bar with the content of the selected page below it.
+type TabsDef struct {
+	Base
+	selected *int
+	pages    []TabPage
+	clicks   []gesture.Click
+	closes   []gesture.Click
+	keyTag   struct{}
+	focused  bool
+	// scroll is the offset in pixels when the tabs are wider than the bar
+	scroll    int
+	scrollTag struct{}
+	tabX      []int
+	tabW      []int
+	// The indicator moves to the selected tab. The point is the position and width of the indicator.
+	last      int
+	indicator Animator[image.Point]
+}
+
+const tabAnimationDuration = 200 * time.Millisecond
+
+var closeIcon *Icon
+
+// Tabs returns a widget with a row of tabs and the content of the selected page below.
+// The index of the selected page is stored in selected, and can be changed by the application.
+func Tabs(th *Theme, selected *int, pages ...TabPage) layout.Widget {
+	// The pages are copied, as closing a tab must not change the caller's slice
+	t := &TabsDef{selected: selected, pages: append([]TabPage(nil), pages...)}
+	t.th = th
+	t.role = Surface
+	t.last = -1
+	t.indicator.Duration = tabAnimationDuration
+	t.clicks = make([]gesture.Click, len(pages))
+	t.closes = make([]gesture.Click, len(pages))
+	return t.Layout
+}
+
+// Layout draws the tab bar and the selected page
+func (t *TabsDef) Layout(gtx C) D {
+	t.handleEvents(gtx)
+	n := len(t.pages)
+	GuiLock.RLock()
+	sel := *t.selected
+	GuiLock.RUnlock()
+	if sel >= n || sel < 0 {
+		sel = Clamp(sel, 0, Max(0, n-1))
+		GuiLock.Lock()
+		*t.selected = sel
+		GuiLock.Unlock()
+	}
+	bar := t.layoutBar(gtx, sel)
+	if n == 0 || t.pages[sel].Widget == nil {
+		return bar
+	}
+	defer op.Offset(image.Pt(0, bar.Size.Y)).Push(gtx.Ops).Pop()
+	c := gtx
+	c.Constraints.Max.Y = Max(0, c.Constraints.Max.Y-bar.Size.Y)
+	c.Constraints.Min.Y = Min(c.Constraints.Min.Y, c.Constraints.Max.Y)
+	dims := t.pages[sel].Widget(c)
+	return D{Size: image.Pt(Max(bar.Size.X, dims.Size.X), bar.Size.Y+dims.Size.Y)}
+}
+
+func (t *TabsDef) setSelected(i int) {
+	GuiLock.Lock()
+	*t.selected = Clamp(i, 0, Max(0, len(t.pages)-1))
+	GuiLock.Unlock()
+}
+
+// close removes page i if its OnClose function accepts it
+func (t *TabsDef) close(i int) {
+	if !t.pages[i].OnClose() {
+		return
+	}
+	t.pages = append(t.pages[:i], t.pages[i+1:]...)
+	t.clicks = append(t.clicks[:i], t.clicks[i+1:]...)
+	t.closes = append(t.closes[:i], t.closes[i+1:]...)
+	GuiLock.Lock()
+	if *t.selected > i || *t.selected >= len(t.pages) {
+		*t.selected = Max(0, *t.selected-1)
+	}
+	GuiLock.Unlock()
+}
+
+func (t *TabsDef) handleEvents(gtx C) {
+	for _, e := range gtx.Events(&t.keyTag) {
+		switch e := e.(type) {
+		case key.FocusEvent:
+			t.focused = e.Focus
+		case key.Event:
+			if e.State != key.Press {
+				continue
+			}
+			GuiLock.RLock()
+			sel := *t.selected
+			GuiLock.RUnlock()
+			switch e.Name {
+			case key.NameLeftArrow:
+				t.setSelected(sel - 1)
+			case key.NameRightArrow:
+				t.setSelected(sel + 1)
+			case key.NameHome:
+				t.setSelected(0)
+			case key.NameEnd:
+				t.setSelected(len(t.pages) - 1)
+			}
+		}
+	}
+	for _, e := range gtx.Events(&t.scrollTag) {
+		if e, ok := e.(pointer.Event); ok && e.Type == pointer.Scroll {
+			t.scroll += int(e.Scroll.X + e.Scroll.Y)
+		}
+	}
+	for i := range t.clicks {
+		for _, e := range t.clicks[i].Events(gtx) {
+			if e.Type == gesture.TypePress && e.Source == pointer.Mouse {
+				key.FocusOp{Tag: &t.keyTag}.Add(gtx.Ops)
+			} else if e.Type == gesture.TypeClick {
+				t.setSelected(i)
+			}
+		}
+	}
+	for i := range t.closes {
+		for _, e := range t.closes[i].Events(gtx) {
+			if e.Type == gesture.TypeClick && t.pages[i].OnClose != nil {
+				t.close(i)
+				// The slices have changed, remaining events are dropped
+				return
+			}
+		}
+	}
+}
+
+// layoutBar draws the tabs, scrolled so that the selected tab is visible
+func (t *TabsDef) layoutBar(gtx C, sel int) D {
+	th := t.th
+	pad := th.ButtonLabelPadding
+	c := gtx
+	c.Constraints.Min = image.Point{}
+	c.Constraints.Max.X = inf
+	// Record the labels to find the size of each tab
+	labels := make([]op.CallOp, len(t.pages))
+	t.tabX = t.tabX[:0]
+	t.tabW = t.tabW[:0]
+	x, textHeight := 0, gtx.Sp(th.TextSize)
+	for i, p := range t.pages {
+		col := th.Fg(Surface)
+		if i == sel {
+			col = th.Bg(Primary)
+		}
+		if gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		macro := op.Record(gtx.Ops)
+		paint.ColorOp{Color: col}.Add(gtx.Ops)
+		dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, th.TextSize, p.Title)
+		labels[i] = macro.Stop()
+		textHeight = Max(textHeight, dims.Size.Y)
+		w := dims.Size.X + gtx.Dp(pad.Left+pad.Right)
+		if p.Icon != nil && p.Title != "" {
+			w += gtx.Sp(th.TextSize) * 3 / 2
+		} else if p.Icon != nil {
+			w += gtx.Sp(th.TextSize)
+		}
+		if p.OnClose != nil {
+			w += gtx.Sp(th.TextSize) * 3 / 2
+		}
+		t.tabX = append(t.tabX, x)
+		t.tabW = append(t.tabW, w)
+		x += w
+	}
+	total := x
+	iconSize := gtx.Sp(th.TextSize)
+	thickness := gtx.Dp(3)
+	height := textHeight + gtx.Dp(pad.Top+pad.Bottom)
+	width := gtx.Constraints.Max.X
+
+	// Keep the selected tab visible after it has changed
+	if sel != t.last && sel < len(t.tabX) {
+		if t.tabX[sel] < t.scroll {
+			t.scroll = t.tabX[sel]
+		} else if t.tabX[sel]+t.tabW[sel] > t.scroll+width {
+			t.scroll = t.tabX[sel] + t.tabW[sel] - width
+		}
+	}
+	t.scroll = Clamp(t.scroll, 0, Max(0, total-width))
+
+	// Animate the indicator from its current position to the selected tab
+	var cur image.Point
+	if sel < len(t.tabX) {
+		to := image.Pt(t.tabX[sel], t.tabW[sel])
+		if sel == t.last && !t.indicator.Running() {
+			// Other changes, like a new window size, move the indicator without animation
+			t.indicator.Set(to)
+		}
+		cur = t.indicator.Animate(gtx, to)
+	}
+	t.last = sel
+
+	bar := image.Rect(0, 0, width, height)
+	defer clip.Rect(bar).Push(gtx.Ops).Pop()
+	paint.Fill(gtx.Ops, th.Bg(Surface))
+	paint.FillShape(gtx.Ops, MulAlpha(th.Fg(Outline), 100), clip.Rect(image.Rect(0, height-gtx.Dp(1), width, height)).Op())
+	if total > width {
+		// Scroll wheel events are only taken when there is something to scroll
+		r := clip.Rect(bar).Push(gtx.Ops)
+		pointer.InputOp{
+			Tag:          &t.scrollTag,
+			Types:        pointer.Scroll,
+			ScrollBounds: image.Rect(-t.scroll, -t.scroll, total-width-t.scroll, total-width-t.scroll),
+		}.Add(gtx.Ops)
+		r.Pop()
+	}
+	keys := key.Set("")
+	if t.focused {
+		keys = key.Set("←|→|⇱|⇲")
+	}
+	if gtx.Queue != nil {
+		key.InputOp{Tag: &t.keyTag, Keys: keys}.Add(gtx.Ops)
+	} else {
+		t.focused = false
+	}
+
+	defer op.Offset(image.Pt(-t.scroll, 0)).Push(gtx.Ops).Pop()
+	for i, p := range t.pages {
+		tab := image.Rect(t.tabX[i], 0, t.tabX[i]+t.tabW[i], height)
+		col := th.Fg(Surface)
+		if i == sel {
+			col = th.Bg(Primary)
+		}
+		if gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		if t.focused && i == sel {
+			paint.FillShape(gtx.Ops, MulAlpha(col, 20), clip.Rect(tab).Op())
+		} else if t.clicks[i].Hovered() {
+			paint.FillShape(gtx.Ops, MulAlpha(th.Fg(Surface), 15), clip.Rect(tab).Op())
+		}
+		r := clip.Rect(tab).Push(gtx.Ops)
+		t.clicks[i].Add(gtx.Ops)
+		pointer.CursorPointer.Add(gtx.Ops)
+		r.Pop()
+
+		dx := tab.Min.X + gtx.Dp(pad.Left)
+		ic := gtx
+		ic.Constraints = layout.Exact(image.Pt(iconSize, iconSize))
+		if p.Icon != nil {
+			o := op.Offset(image.Pt(dx, (height-iconSize)/2)).Push(gtx.Ops)
+			_ = p.Icon.Layout(ic, col)
+			o.Pop()
+			dx += iconSize
+			if p.Title != "" {
+				dx += iconSize / 2
+			}
+		}
+		o := op.Offset(image.Pt(dx, gtx.Dp(pad.Top))).Push(gtx.Ops)
+		labels[i].Add(gtx.Ops)
+		o.Pop()
+		if p.OnClose != nil {
+			size := iconSize * 3 / 4
+			pos := image.Pt(tab.Max.X-gtx.Dp(pad.Right)-size, (height-size)/2)
+			area := image.Rectangle{Min: pos, Max: pos.Add(image.Pt(size, size))}.Inset(-size / 4)
+			if t.closes[i].Hovered() {
+				paint.FillShape(gtx.Ops, MulAlpha(col, 40), clip.UniformRRect(area, area.Dx()/2).Op(gtx.Ops))
+			}
+			o := op.Offset(pos).Push(gtx.Ops)
+			ic.Constraints = layout.Exact(image.Pt(size, size))
+			_ = closeIcon.Layout(ic, col)
+			o.Pop()
+			r := clip.Rect(area).Push(gtx.Ops)
+			t.closes[i].Add(gtx.Ops)
+			r.Pop()
+		}
+	}
+	// Draw the selection indicator at the bottom of the bar
+	if len(t.pages) > 0 {
+		ind := image.Rect(cur.X, height-thickness, cur.X+cur.Y, height)
+		col := th.Bg(Primary)
+		if gtx.Queue == nil {
+			col = Disabled(col)
+		}
+		paint.FillShape(gtx.Ops, col, clip.RRect{Rect: ind, NW: thickness, NE: thickness}.Op(gtx.Ops))
+	}
+	return D{Size: bar.Max}
+}
+
+func init() {
+	closeIcon, _ = NewIcon(icons.NavigationClose)
+}
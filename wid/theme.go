@@ -49,158 +49,204 @@ const (
 	Undefined
 )
 
-// Tone is the google material tone implementation
-// See: https://m3.material.io/styles/color/the-color-system/key-colors-tones
-func Tone(c color.NRGBA, tone int) color.NRGBA {
-	h, s, _ := Rgb2hsl(c)
-	switch {
-	case tone < 5:
-		return Black
-	case tone < 15:
-		return Hsl2rgb(h, s, 0.1)
-	case tone < 25:
-		return Hsl2rgb(h, s, 0.2)
-	case tone < 35:
-		return Hsl2rgb(h, s, 0.3)
-	case tone < 45:
-		return Hsl2rgb(h, s, 0.35)
-	case tone < 55:
-		return Hsl2rgb(h, s, 0.4)
-	case tone < 65:
-		return Hsl2rgb(h, s, 0.45)
-	case tone < 75:
-		return Hsl2rgb(h, s, 0.6)
-	case tone < 85:
-		return Hsl2rgb(h, s, 0.68)
-	case tone < 94:
-		return Hsl2rgb(h, s, 0.75)
-	case tone < 96:
-		return Hsl2rgb(h, s, 0.85)
-	case tone < 100:
-		return Hsl2rgb(h, s, 0.95)
+// Fg returns the text/icon color. This is the OnPrimary, OnBackground... colors
+func (th *Theme) Fg(kind UIRole) color.NRGBA {
+	if th.shown.from != nil {
+		return th.shown.fg(kind)
 	}
-	return White
+	return th.Pallet.fg(kind, th.DarkMode)
 }
 
-// Fg returns the text/icon color. This is the OnPrimary, OnBackground... colors
-func (th *Theme) Fg(kind UIRole) color.NRGBA {
-	if !th.DarkMode {
+// Bg returns the background color used to fill the element.
+func (th *Theme) Bg(kind UIRole) color.NRGBA {
+	if th.shown.from != nil {
+		return th.shown.bg(kind)
+	}
+	return th.Pallet.bg(kind, th.DarkMode)
+}
+
+// themeState is the settings that the colors are calculated from
+type themeState struct {
+	dark   bool
+	pallet Pallet
+}
+
+// themeColors is the colors on screen. While fading, they are interpolated from the
+// colors that were on screen when the settings changed, which can be a fade too.
+type themeColors struct {
+	themeState
+	from *themeColors
+	fade float32
+}
+
+func (c *themeColors) fg(kind UIRole) color.NRGBA {
+	col := c.pallet.fg(kind, c.dark)
+	if c.from != nil {
+		col = Interpolate(c.from.fg(kind), col, c.fade)
+	}
+	return col
+}
+
+func (c *themeColors) bg(kind UIRole) color.NRGBA {
+	col := c.pallet.bg(kind, c.dark)
+	if c.from != nil {
+		col = Interpolate(c.from.bg(kind), col, c.fade)
+	}
+	return col
+}
+
+// animateTransition is called by the run loop before each frame. When DarkMode or the
+// Pallet has changed since the last frame, Fg() and Bg() will fade from the colors on
+// screen to the new ones over TransitionDuration. The derived colors follow the fade.
+func (th *Theme) animateTransition(gtx C) {
+	state := themeState{dark: th.DarkMode, pallet: th.Pallet}
+	if th.shown.pallet == (Pallet{}) {
+		th.shown = themeColors{themeState: state}
+	}
+	changed := state != th.shown.themeState
+	if changed {
+		if th.TransitionDuration > 0 {
+			// The fade starts from the colors on screen, also when a fade is running
+			from := th.shown
+			th.shown = themeColors{themeState: state, from: &from}
+			th.transition.Duration = th.TransitionDuration
+			th.transition.Start(gtx.Now, 0, 1)
+		} else {
+			th.shown = themeColors{themeState: state}
+		}
+	}
+	if th.shown.from != nil {
+		th.shown.fade = th.transition.Value(gtx)
+		if !th.transition.Running() {
+			th.shown.from = nil
+		}
+		changed = true
+	}
+	if changed {
+		th.deriveColors()
+	}
+}
+
+// fg returns the text/icon color for the pallet, without transitions.
+// The tones are the Material 3 color scheme.
+func (p *Pallet) fg(kind UIRole, dark bool) color.NRGBA {
+	if !dark {
 		switch kind {
 		case Canvas: // Black
-			return Tone(th.Pallet.NeutralColor, 0)
-		case Surface: // Black
-			return Tone(th.Pallet.NeutralColor, 0)
-		case SurfaceVariant: // Black
-			return Tone(th.Pallet.NeutralVariantColor, 0)
+			return Tone(p.NeutralColor, 0)
+		case Surface: // Almost black
+			return Tone(p.NeutralColor, 10)
+		case SurfaceVariant: // Dark gray
+			return Tone(p.NeutralVariantColor, 30)
 		case Outline:
-			return Tone(th.Pallet.NeutralColor, 50)
+			return Tone(p.NeutralVariantColor, 50)
 		case Primary:
-			return Tone(th.Pallet.PrimaryColor, 100)
+			return Tone(p.PrimaryColor, 100)
 		case Secondary:
-			return Tone(th.Pallet.SecondaryColor, 100)
+			return Tone(p.SecondaryColor, 100)
 		case Tertiary:
-			return Tone(th.Pallet.TertiaryColor, 100)
+			return Tone(p.TertiaryColor, 100)
 		case Error:
-			return Tone(th.Pallet.TertiaryColor, 100)
+			return Tone(p.ErrorColor, 100)
 		case PrimaryContainer:
-			return Tone(th.Pallet.PrimaryColor, 10)
+			return Tone(p.PrimaryColor, 10)
 		case SecondaryContainer:
-			return Tone(th.Pallet.SecondaryColor, 10)
+			return Tone(p.SecondaryColor, 10)
 		case TertiaryContainer:
-			return Tone(th.Pallet.TertiaryColor, 10)
+			return Tone(p.TertiaryColor, 10)
 		case ErrorContainer:
-			return Tone(th.Pallet.ErrorColor, 10)
+			return Tone(p.ErrorColor, 10)
 		default:
-			return Tone(th.Pallet.NeutralColor, 10)
+			return Tone(p.NeutralColor, 10)
 		}
 	} else {
 		switch kind {
 		case Canvas: // White
-			return Tone(th.Pallet.NeutralColor, 80)
+			return Tone(p.NeutralColor, 80)
 		case Surface: // Light silver
-			return Tone(th.Pallet.NeutralColor, 100)
-		case SurfaceVariant: // Some other very light color
-			return Tone(th.Pallet.NeutralVariantColor, 90)
+			return Tone(p.NeutralColor, 90)
+		case SurfaceVariant: // Some other light color
+			return Tone(p.NeutralVariantColor, 80)
 		case Outline:
-			return Tone(th.Pallet.NeutralColor, 60)
+			return Tone(p.NeutralVariantColor, 60)
 		case Primary:
-			return Tone(th.Pallet.PrimaryColor, 20)
+			return Tone(p.PrimaryColor, 20)
 		case Secondary:
-			return Tone(th.Pallet.SecondaryColor, 20)
+			return Tone(p.SecondaryColor, 20)
 		case Tertiary:
-			return Tone(th.Pallet.TertiaryColor, 20)
+			return Tone(p.TertiaryColor, 20)
 		case Error:
-			return Tone(th.Pallet.ErrorColor, 20)
+			return Tone(p.ErrorColor, 20)
 		case PrimaryContainer:
-			return Tone(th.Pallet.PrimaryColor, 90)
+			return Tone(p.PrimaryColor, 90)
 		case SecondaryContainer:
-			return Tone(th.Pallet.SecondaryColor, 90)
+			return Tone(p.SecondaryColor, 90)
 		case TertiaryContainer:
-			return Tone(th.Pallet.TertiaryColor, 90)
+			return Tone(p.TertiaryColor, 90)
 		case ErrorContainer:
-			return Tone(th.Pallet.TertiaryColor, 90)
+			return Tone(p.ErrorColor, 90)
 		default:
-			return Tone(th.Pallet.NeutralColor, 90)
+			return Tone(p.NeutralColor, 90)
 		}
 	}
 	return RGB(0x000000)
 }
 
-// Bg returns the background color used to fill the element.
-func (th *Theme) Bg(kind UIRole) color.NRGBA {
-	if !th.DarkMode {
+// bg returns the background color for the pallet, without transitions
+func (p *Pallet) bg(kind UIRole, dark bool) color.NRGBA {
+	if !dark {
 		switch kind {
 		case Canvas: // White background
-			return Tone(th.Pallet.NeutralColor, 100)
+			return Tone(p.NeutralColor, 100)
 		case Surface: // Light silver background
-			return Tone(th.Pallet.NeutralColor, 99)
+			return Tone(p.NeutralColor, 99)
 		case SurfaceVariant: // Some other light background
-			return Tone(th.Pallet.NeutralVariantColor, 90)
+			return Tone(p.NeutralVariantColor, 90)
 		case Primary:
-			return Tone(th.Pallet.PrimaryColor, 40)
+			return Tone(p.PrimaryColor, 40)
 		case Secondary:
-			return Tone(th.Pallet.SecondaryColor, 40)
+			return Tone(p.SecondaryColor, 40)
 		case Tertiary:
-			return Tone(th.Pallet.TertiaryColor, 40)
+			return Tone(p.TertiaryColor, 40)
 		case Error:
-			return Tone(th.Pallet.ErrorColor, 40)
+			return Tone(p.ErrorColor, 40)
 		case PrimaryContainer:
-			return Tone(th.Pallet.PrimaryColor, 90)
+			return Tone(p.PrimaryColor, 90)
 		case SecondaryContainer:
-			return Tone(th.Pallet.SecondaryColor, 90)
+			return Tone(p.SecondaryColor, 90)
 		case TertiaryContainer:
-			return Tone(th.Pallet.TertiaryColor, 90)
+			return Tone(p.TertiaryColor, 90)
 		case ErrorContainer:
-			return Tone(th.Pallet.ErrorColor, 90)
+			return Tone(p.ErrorColor, 90)
 		default:
-			return Tone(th.Pallet.NeutralColor, 99)
+			return Tone(p.NeutralColor, 99)
 		}
 	} else {
 		switch kind {
 		case Canvas: // Black background
-			return Tone(th.Pallet.NeutralColor, 0)
+			return Tone(p.NeutralColor, 0)
 		case Surface: // Dark gray background
-			return Tone(th.Pallet.NeutralColor, 10)
-		case SurfaceVariant: // Another very dark background
-			return Tone(th.Pallet.NeutralVariantColor, 20)
+			return Tone(p.NeutralColor, 10)
+		case SurfaceVariant: // Another dark background
+			return Tone(p.NeutralVariantColor, 30)
 		case Primary:
-			return Tone(th.Pallet.PrimaryColor, 80)
+			return Tone(p.PrimaryColor, 80)
 		case Secondary:
-			return Tone(th.Pallet.SecondaryColor, 80)
+			return Tone(p.SecondaryColor, 80)
 		case Tertiary:
-			return Tone(th.Pallet.TertiaryColor, 80)
+			return Tone(p.TertiaryColor, 80)
 		case Error:
-			return Tone(th.Pallet.ErrorColor, 80)
+			return Tone(p.ErrorColor, 80)
 		case PrimaryContainer:
-			return Tone(th.Pallet.PrimaryColor, 30)
+			return Tone(p.PrimaryColor, 30)
 		case SecondaryContainer:
-			return Tone(th.Pallet.SecondaryColor, 30)
+			return Tone(p.SecondaryColor, 30)
 		case TertiaryContainer:
-			return Tone(th.Pallet.TertiaryColor, 30)
+			return Tone(p.TertiaryColor, 30)
 		case ErrorContainer:
-			return Tone(th.Pallet.TertiaryColor, 30)
+			return Tone(p.ErrorColor, 30)
 		default:
-			return Tone(th.Pallet.NeutralColor, 10)
+			return Tone(p.NeutralColor, 10)
 		}
 	}
 	return RGB(0xFFFFFFFF)
@@ -219,7 +265,12 @@ type Pallet struct {
 // Theme contains color/layout settings for all widgets
 type Theme struct {
 	Pallet
-	DarkMode            bool
+	DarkMode bool
+	// TransitionDuration is the time used to fade the colors when DarkMode or the
+	// Pallet is changed. Zero changes the colors immediately. The colors derived from
+	// the pallet, like BorderColor and TooltipBackground, change too, unless they are
+	// set by the application.
+	TransitionDuration  time.Duration
 	Shaper              *text.Shaper
 	TextSize            unit.Sp
 	DefaultFont         text.Font
@@ -267,14 +318,26 @@ type Theme struct {
 	// FadeDuration is the amount of time it takes the tooltip to fade in
 	// and out.
 	FadeDuration time.Duration
-	RowPadTop    unit.Sp
-	RowPadBtm    unit.Sp
+	// WarmUpDuration is the time after a tooltip was shown, where moving to another
+	// widget shows its tooltip without the hover delay. Zero disables the warm-up.
+	WarmUpDuration time.Duration
+	RowPadTop      unit.Sp
+	RowPadBtm      unit.Sp
 	// Scroll bar size
 	ScrollMajorPadding unit.Sp
 	ScrollMinorPadding unit.Sp
 	ScrollMajorMinLen  unit.Sp
 	ScrollMinorWidth   unit.Sp
 	ScrollCornerRadius unit.Sp
+	// Focus ring drawn around the focused widget. A zero color uses the primary color.
+	FocusRingColor color.NRGBA
+	FocusRingWidth unit.Dp
+	FocusRingGap   unit.Dp
+	// The state of the color transition
+	shown      themeColors
+	transition Animator[float32]
+	// derived is the colors last set by deriveColors
+	derived [9]color.NRGBA
 }
 
 func uniformPadding(p unit.Dp) layout.Inset {
@@ -315,15 +378,11 @@ func NewTheme(fontCollection []text.FontFace, fontSize unit.Sp, colors ...color.
 	t.FingerSize = unit.Dp(38)
 	// Borders around edit fields
 	t.BorderThickness = unit.Dp(t.TextSize) * 0.08
-	t.BorderColor = t.Fg(Outline)
-	t.BorderColorHovered = t.Fg(Primary)
-	t.BorderColorActive = t.Fg(Primary)
 	t.BorderCornerRadius = v * 3
 	// Shadow
 	t.Elevation = unit.Dp(t.TextSize) * 0.5
 	// Text
 	t.OutsidePadding = uniformPadding(2.5 * v)
-	t.SelectionColor = MulAlpha(t.Fg(Primary), 0x60)
 	t.InsidePadding = uniformPadding(2.5 * v)
 	// Buttons
 	// ButtonPadding is the margin outside a button, giving distance to other elements
@@ -335,14 +394,14 @@ func NewTheme(fontCollection []text.FontFace, fontSize unit.Sp, colors ...color.
 	t.TooltipInset = layout.UniformInset(unit.Dp(10))
 	t.TooltipCornerRadius = t.BorderCornerRadius
 	t.TooltipWidth = v * 250
-	t.TooltipBackground = t.Bg(SecondaryContainer)
-	t.TooltipOnBackground = t.Fg(SecondaryContainer)
+	t.HoverDelay = time.Millisecond * 1200
+	t.LongPressDelay = time.Millisecond * 1200
+	t.LongPressDuration = time.Millisecond * 1200
+	t.FadeDuration = time.Millisecond * 500
+	t.WarmUpDuration = time.Millisecond * 1000
+	t.TransitionDuration = time.Millisecond * 300
 	// Resizer
-	t.SashColor = WithAlpha(t.Fg(Surface), 0x80)
 	t.SashWidth = v * 4
-	// Switch
-	t.TrackColor = WithAlpha(t.Fg(Primary), 0x40)
-	t.DotColor = t.Fg(Primary)
 	t.RowPadTop = t.TextSize * 0.0
 	t.RowPadBtm = t.TextSize * 0.0
 
@@ -351,9 +410,45 @@ func NewTheme(fontCollection []text.FontFace, fontSize unit.Sp, colors ...color.
 	t.ScrollMajorMinLen = t.TextSize / 1.25
 	t.ScrollMinorWidth = t.TextSize / 1.5
 	t.ScrollCornerRadius = 3
+	// Focus ring
+	t.FocusRingWidth = v * 2
+	t.FocusRingGap = v
+	t.deriveColors()
+	return t
+}
+
+// NewThemeFromSeed creates a new theme where all the pallet colors are derived from one seed color
+func NewThemeFromSeed(fontCollection []text.FontFace, fontSize unit.Sp, seed color.NRGBA) *Theme {
+	t := NewTheme(fontCollection, fontSize)
+	t.Pallet = PalletFromSeed(seed)
+	t.deriveColors()
 	return t
 }
 
+// deriveColors sets the theme colors that are calculated from the pallet. Colors that
+// the application has changed since they were derived are kept.
+func (t *Theme) deriveColors() {
+	for i, c := range [len(t.derived)]struct {
+		dst *color.NRGBA
+		col color.NRGBA
+	}{
+		{&t.BorderColor, t.Fg(Outline)},
+		{&t.BorderColorHovered, t.Fg(Primary)},
+		{&t.BorderColorActive, t.Fg(Primary)},
+		{&t.SelectionColor, MulAlpha(t.Fg(Primary), 0x60)},
+		{&t.TooltipBackground, t.Bg(SecondaryContainer)},
+		{&t.TooltipOnBackground, t.Fg(SecondaryContainer)},
+		{&t.SashColor, WithAlpha(t.Fg(Surface), 0x80)},
+		{&t.TrackColor, WithAlpha(t.Fg(Primary), 0x40)},
+		{&t.DotColor, t.Fg(Primary)},
+	} {
+		if *c.dst == t.derived[i] {
+			*c.dst = c.col
+			t.derived[i] = c.col
+		}
+	}
+}
+
 func mustIcon(ic *Icon, err error) *Icon {
 	if err != nil {
 		panic(err)
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"testing"
+	"time"
+
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+func TestDeriveColorsFade(t *testing.T) {
+	var ops op.Ops
+	start := time.Unix(1000, 0)
+	gtx := layout.Context{Ops: &ops, Now: start}
+	th := NewTheme(gofont.Collection(), 16)
+	th.TransitionDuration = time.Second
+	th.animateTransition(gtx)
+	light := th.BorderColor
+	// Colors set by the application are kept
+	th.SashColor = RGB(0x123456)
+	th.DarkMode = true
+	th.animateTransition(gtx)
+	if th.BorderColor != light {
+		t.Errorf("border color changed before the fade")
+	}
+	gtx.Now = start.Add(500 * time.Millisecond)
+	th.animateTransition(gtx)
+	mid := th.BorderColor
+	gtx.Now = start.Add(2 * time.Second)
+	th.animateTransition(gtx)
+	dark := th.BorderColor
+	if dark != th.Pallet.fg(Outline, true) || dark == light {
+		t.Errorf("border color is %s after the fade, want %s", Hex(dark), Hex(th.Pallet.fg(Outline, true)))
+	}
+	if mid == light || mid == dark {
+		t.Errorf("border color %s does not fade from %s to %s", Hex(mid), Hex(light), Hex(dark))
+	}
+	if th.TooltipBackground != th.Bg(SecondaryContainer) {
+		t.Errorf("tooltip background %s, want %s", Hex(th.TooltipBackground), Hex(th.Bg(SecondaryContainer)))
+	}
+	if th.SashColor != RGB(0x123456) {
+		t.Errorf("the sash color set by the application was changed to %s", Hex(th.SashColor))
+	}
+}
@@ -0,0 +1,523 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+// ThemeFile is the declarative form of a theme, as saved in JSON or TOML files.
+// Colors are hex strings like "#45682A", sizes are in dp, the text size is in sp and
+// the timings are in milliseconds. Fields that are missing get the defaults from NewTheme().
+// Unknown fields are errors.
+//
+// Only the subset of TOML needed for theme files is supported: # comments, [table]
+// headers without dots, and one key = value per line, where the value is a basic
+// "string", true, false, a decimal number, or a [list] of numbers on one line.
+// Literal strings, multi-line values, dotted keys, inline tables, dates, inf and nan
+// are not supported.
+type ThemeFile struct {
+	DarkMode *bool       `json:"darkMode,omitempty"`
+	TextSize *float32    `json:"textSize,omitempty"`
+	Pallet   ThemeColors `json:"pallet"`
+	Radius   ThemeRadius `json:"radius"`
+	Padding  ThemePads   `json:"padding"`
+	Timing   ThemeTiming `json:"timing"`
+}
+
+// ThemeColors is the key colors of a theme file
+type ThemeColors struct {
+	Primary        string `json:"primary,omitempty"`
+	Secondary      string `json:"secondary,omitempty"`
+	Tertiary       string `json:"tertiary,omitempty"`
+	Error          string `json:"error,omitempty"`
+	Neutral        string `json:"neutral,omitempty"`
+	NeutralVariant string `json:"neutralVariant,omitempty"`
+}
+
+// ThemeRadius is the corner radii of a theme file
+type ThemeRadius struct {
+	Border  *float32 `json:"border,omitempty"`
+	Button  *float32 `json:"button,omitempty"`
+	Tooltip *float32 `json:"tooltip,omitempty"`
+	Scroll  *float32 `json:"scroll,omitempty"`
+}
+
+// ThemePads is the paddings of a theme file
+type ThemePads struct {
+	Outside     Padding `json:"outside,omitempty"`
+	Inside      Padding `json:"inside,omitempty"`
+	Button      Padding `json:"button,omitempty"`
+	ButtonLabel Padding `json:"buttonLabel,omitempty"`
+	Icon        Padding `json:"icon,omitempty"`
+	Tooltip     Padding `json:"tooltip,omitempty"`
+}
+
+// ThemeTiming is the durations of a theme file, in milliseconds
+type ThemeTiming struct {
+	HoverDelay        *int `json:"hoverDelay,omitempty"`
+	LongPressDelay    *int `json:"longPressDelay,omitempty"`
+	LongPressDuration *int `json:"longPressDuration,omitempty"`
+	Fade              *int `json:"fade,omitempty"`
+	WarmUp            *int `json:"warmUp,omitempty"`
+	Transition        *int `json:"transition,omitempty"`
+}
+
+// Padding is a padding in dp. It is saved as one number when all sides are equal,
+// and else as [top, right, bottom, left].
+type Padding []float32
+
+// Limits used when validating theme files
+const (
+	minTextSize = 4
+	maxTextSize = 100
+	maxDp       = 200
+	maxMs       = 60000
+)
+
+// SaveTheme writes the theme to a file. Files ending in .toml are written as TOML,
+// all others as JSON.
+func SaveTheme(th *Theme, filename string) error {
+	var data []byte
+	var err error
+	if isToml(filename) {
+		data, err = th.File().MarshalTOML()
+	} else {
+		data, err = json.MarshalIndent(th.File(), "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// LoadTheme reads a theme saved by SaveTheme, or written by hand. Files ending in .toml
+// are read as TOML, all others as JSON. The file is validated, and missing fields get
+// the defaults from NewTheme().
+func LoadTheme(fontCollection []text.FontFace, filename string) (*Theme, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var f ThemeFile
+	if isToml(filename) {
+		err = f.UnmarshalTOML(data)
+	} else {
+		err = decodeStrict(data, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", filename, err)
+	}
+	th, err := f.Theme(fontCollection)
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", filename, err)
+	}
+	return th, nil
+}
+
+func isToml(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".toml")
+}
+
+// decodeStrict decodes JSON, returning an error for fields that are not in v
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// File returns the theme settings that are saved in theme files
+func (th *Theme) File() ThemeFile {
+	dp := func(v unit.Dp) *float32 {
+		f := float32(v)
+		return &f
+	}
+	ms := func(d time.Duration) *int {
+		v := int(d.Milliseconds())
+		return &v
+	}
+	dark := th.DarkMode
+	size := float32(th.TextSize)
+	scroll := float32(th.ScrollCornerRadius)
+	return ThemeFile{
+		DarkMode: &dark,
+		TextSize: &size,
+		Pallet: ThemeColors{
+			Primary:        Hex(th.PrimaryColor),
+			Secondary:      Hex(th.SecondaryColor),
+			Tertiary:       Hex(th.TertiaryColor),
+			Error:          Hex(th.ErrorColor),
+			Neutral:        Hex(th.NeutralColor),
+			NeutralVariant: Hex(th.NeutralVariantColor),
+		},
+		Radius: ThemeRadius{
+			Border:  dp(th.BorderCornerRadius),
+			Button:  dp(th.ButtonCornerRadius),
+			Tooltip: dp(th.TooltipCornerRadius),
+			Scroll:  &scroll,
+		},
+		Padding: ThemePads{
+			Outside:     insetPadding(th.OutsidePadding),
+			Inside:      insetPadding(th.InsidePadding),
+			Button:      insetPadding(th.ButtonPadding),
+			ButtonLabel: insetPadding(th.ButtonLabelPadding),
+			Icon:        insetPadding(th.IconInset),
+			Tooltip:     insetPadding(th.TooltipInset),
+		},
+		Timing: ThemeTiming{
+			HoverDelay:        ms(th.HoverDelay),
+			LongPressDelay:    ms(th.LongPressDelay),
+			LongPressDuration: ms(th.LongPressDuration),
+			Fade:              ms(th.FadeDuration),
+			WarmUp:            ms(th.WarmUpDuration),
+			Transition:        ms(th.TransitionDuration),
+		},
+	}
+}
+
+// Theme validates the file and returns a new theme with its settings
+func (f ThemeFile) Theme(fontCollection []text.FontFace) (*Theme, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	size := unit.Sp(14)
+	if f.TextSize != nil {
+		size = unit.Sp(*f.TextSize)
+	}
+	th := NewTheme(fontCollection, size)
+	for _, c := range []struct {
+		hex string
+		dst *color.NRGBA
+	}{
+		{f.Pallet.Primary, &th.PrimaryColor},
+		{f.Pallet.Secondary, &th.SecondaryColor},
+		{f.Pallet.Tertiary, &th.TertiaryColor},
+		{f.Pallet.Error, &th.ErrorColor},
+		{f.Pallet.Neutral, &th.NeutralColor},
+		{f.Pallet.NeutralVariant, &th.NeutralVariantColor},
+	} {
+		if c.hex != "" {
+			*c.dst, _ = ParseHex(c.hex)
+		}
+	}
+	if f.DarkMode != nil {
+		th.DarkMode = *f.DarkMode
+	}
+	th.deriveColors()
+	for _, r := range []struct {
+		v   *float32
+		dst *unit.Dp
+	}{
+		{f.Radius.Border, &th.BorderCornerRadius},
+		{f.Radius.Button, &th.ButtonCornerRadius},
+		{f.Radius.Tooltip, &th.TooltipCornerRadius},
+	} {
+		if r.v != nil {
+			*r.dst = unit.Dp(*r.v)
+		}
+	}
+	if f.Radius.Scroll != nil {
+		th.ScrollCornerRadius = unit.Sp(*f.Radius.Scroll)
+	}
+	for _, p := range []struct {
+		v   Padding
+		dst *layout.Inset
+	}{
+		{f.Padding.Outside, &th.OutsidePadding},
+		{f.Padding.Inside, &th.InsidePadding},
+		{f.Padding.Button, &th.ButtonPadding},
+		{f.Padding.ButtonLabel, &th.ButtonLabelPadding},
+		{f.Padding.Icon, &th.IconInset},
+		{f.Padding.Tooltip, &th.TooltipInset},
+	} {
+		if p.v != nil {
+			*p.dst = p.v.Inset()
+		}
+	}
+	for _, t := range []struct {
+		v   *int
+		dst *time.Duration
+	}{
+		{f.Timing.HoverDelay, &th.HoverDelay},
+		{f.Timing.LongPressDelay, &th.LongPressDelay},
+		{f.Timing.LongPressDuration, &th.LongPressDuration},
+		{f.Timing.Fade, &th.FadeDuration},
+		{f.Timing.WarmUp, &th.WarmUpDuration},
+		{f.Timing.Transition, &th.TransitionDuration},
+	} {
+		if t.v != nil {
+			*t.dst = time.Duration(*t.v) * time.Millisecond
+		}
+	}
+	return th, nil
+}
+
+// Validate checks that the colors can be parsed and that all values are within limits.
+// The error names the first field that is wrong.
+func (f ThemeFile) Validate() error {
+	if f.TextSize != nil && !(*f.TextSize >= minTextSize && *f.TextSize <= maxTextSize) {
+		return fmt.Errorf("textSize %g is outside %d..%d", *f.TextSize, minTextSize, maxTextSize)
+	}
+	p := f.Pallet
+	for _, c := range []struct{ name, hex string }{
+		{"primary", p.Primary}, {"secondary", p.Secondary}, {"tertiary", p.Tertiary},
+		{"error", p.Error}, {"neutral", p.Neutral}, {"neutralVariant", p.NeutralVariant},
+	} {
+		if c.hex == "" {
+			continue
+		}
+		if _, err := ParseHex(c.hex); err != nil {
+			return fmt.Errorf("pallet.%s: %w", c.name, err)
+		}
+	}
+	r := f.Radius
+	for _, v := range []struct {
+		name string
+		v    *float32
+	}{
+		{"border", r.Border}, {"button", r.Button}, {"tooltip", r.Tooltip}, {"scroll", r.Scroll},
+	} {
+		if v.v != nil && !(*v.v >= 0 && *v.v <= maxDp) {
+			return fmt.Errorf("radius.%s %g is outside 0..%d", v.name, *v.v, maxDp)
+		}
+	}
+	pads := f.Padding
+	for _, v := range []struct {
+		name string
+		v    Padding
+	}{
+		{"outside", pads.Outside}, {"inside", pads.Inside}, {"button", pads.Button},
+		{"buttonLabel", pads.ButtonLabel}, {"icon", pads.Icon}, {"tooltip", pads.Tooltip},
+	} {
+		if v.v == nil {
+			continue
+		}
+		if len(v.v) != 1 && len(v.v) != 4 {
+			return fmt.Errorf("padding.%s must have 1 or 4 values", v.name)
+		}
+		for _, x := range v.v {
+			if !(x >= 0 && x <= maxDp) {
+				return fmt.Errorf("padding.%s %g is outside 0..%d", v.name, x, maxDp)
+			}
+		}
+	}
+	t := f.Timing
+	for _, v := range []struct {
+		name string
+		v    *int
+	}{
+		{"hoverDelay", t.HoverDelay}, {"longPressDelay", t.LongPressDelay},
+		{"longPressDuration", t.LongPressDuration}, {"fade", t.Fade},
+		{"warmUp", t.WarmUp}, {"transition", t.Transition},
+	} {
+		if v.v != nil && (*v.v < 0 || *v.v > maxMs) {
+			return fmt.Errorf("timing.%s %d is outside 0..%d", v.name, *v.v, maxMs)
+		}
+	}
+	return nil
+}
+
+func insetPadding(in layout.Inset) Padding {
+	if in.Top == in.Right && in.Top == in.Bottom && in.Top == in.Left {
+		return Padding{float32(in.Top)}
+	}
+	return Padding{float32(in.Top), float32(in.Right), float32(in.Bottom), float32(in.Left)}
+}
+
+// Inset returns the padding as a layout inset
+func (p Padding) Inset() layout.Inset {
+	switch len(p) {
+	case 1:
+		return uniformPadding(unit.Dp(p[0]))
+	case 4:
+		return layout.Inset{Top: unit.Dp(p[0]), Right: unit.Dp(p[1]), Bottom: unit.Dp(p[2]), Left: unit.Dp(p[3])}
+	}
+	return layout.Inset{}
+}
+
+// MarshalJSON writes the padding as a number when all sides are equal
+func (p Padding) MarshalJSON() ([]byte, error) {
+	if len(p) == 1 {
+		return json.Marshal(p[0])
+	}
+	return json.Marshal([]float32(p))
+}
+
+// UnmarshalJSON reads a number or a list of numbers
+func (p *Padding) UnmarshalJSON(data []byte) error {
+	var v float32
+	if err := json.Unmarshal(data, &v); err == nil {
+		*p = Padding{v}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]float32)(p))
+}
+
+// MarshalTOML writes the theme file as TOML, with the pallet, radius, padding and
+// timing as tables.
+func (f ThemeFile) MarshalTOML() ([]byte, error) {
+	// The JSON form is reused, so that the keys are the same in both formats
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	var tables []string
+	for _, k := range sortedKeys(m) {
+		if table, ok := m[k].(map[string]interface{}); ok {
+			if len(table) > 0 {
+				tables = append(tables, k)
+			}
+			continue
+		}
+		fmt.Fprintf(&buf, "%s = %s\n", k, tomlValue(m[k]))
+	}
+	for _, t := range tables {
+		table := m[t].(map[string]interface{})
+		fmt.Fprintf(&buf, "\n[%s]\n", t)
+		for _, k := range sortedKeys(table) {
+			fmt.Fprintf(&buf, "%s = %s\n", k, tomlValue(table[k]))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalTOML reads a theme file in TOML. It supports the subset of TOML used by
+// theme files: tables, and keys with strings, numbers, booleans and lists of numbers.
+// As in TOML, a table or a key that is defined twice is an error.
+func (f *ThemeFile) UnmarshalTOML(data []byte) error {
+	m := map[string]interface{}{}
+	table := m
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := m[name]; ok {
+				return fmt.Errorf("line %d: duplicate table [%s]", n, name)
+			}
+			table = map[string]interface{}{}
+			m[name] = table
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", n)
+		}
+		value, err := parseTomlValue(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		key := strings.Trim(strings.TrimSpace(k), `"`)
+		if _, ok := table[key]; ok {
+			return fmt.Errorf("line %d: duplicate key %s", n, key)
+		}
+		table[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return decodeStrict(data, f)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tomlValue formats a value decoded from JSON
+func tomlValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		s := make([]string, len(v))
+		for i, x := range v {
+			s[i] = tomlValue(x)
+		}
+		return "[" + strings.Join(s, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// stripComment removes a # comment that is not inside a string
+func stripComment(line string) string {
+	quoted, escaped := false, false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == '#' && !quoted:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlNumber matches the decimal integers and floats of TOML
+var tomlNumber = regexp.MustCompile(`^[+-]?[0-9](_?[0-9])*(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+
+func parseTomlValue(s string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		var list []interface{}
+		for _, x := range strings.Split(s[1:len(s)-1], ",") {
+			if x = strings.TrimSpace(x); x == "" {
+				continue
+			}
+			v, err := parseTomlValue(x)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	// ParseFloat also accepts inf, nan and hex numbers, which can not be saved as JSON
+	if !tomlNumber.MatchString(s) {
+		return nil, fmt.Errorf("invalid value %s", s)
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %s", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+)
+
+func testThemeFile() ThemeFile {
+	th := NewTheme(gofont.Collection(), 16)
+	th.DarkMode = true
+	th.PrimaryColor = RGB(0x6750A4)
+	th.ButtonPadding = layout.Inset{Top: 1, Right: 2, Bottom: 3, Left: 4}
+	th.FadeDuration = 0
+	th.TransitionDuration = 450 * time.Millisecond
+	return th.File()
+}
+
+func TestThemeFileJSON(t *testing.T) {
+	f := testThemeFile()
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g ThemeFile
+	if err := decodeStrict(data, &g); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, g) {
+		t.Errorf("JSON round trip changed the file\n%+v\n%+v", f, g)
+	}
+}
+
+func TestThemeFileTOML(t *testing.T) {
+	f := testThemeFile()
+	data, err := f.MarshalTOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g ThemeFile
+	if err := g.UnmarshalTOML(data); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(f, g) {
+		t.Errorf("TOML round trip changed the file\n%+v\n%+v\n%s", f, g, data)
+	}
+}
+
+func TestThemeFileTheme(t *testing.T) {
+	f := testThemeFile()
+	th, err := f.Theme(gofont.Collection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := th.File(); !reflect.DeepEqual(f, g) {
+		t.Errorf("Theme().File() changed the file\n%+v\n%+v", f, g)
+	}
+	// The derived colors must be calculated for dark mode
+	if th.BorderColor != th.Fg(Outline) || th.DotColor != th.Fg(Primary) {
+		t.Errorf("derived colors are not set for dark mode")
+	}
+}
+
+func TestUnmarshalTOML(t *testing.T) {
+	size := float32(12.5)
+	dark := true
+	hover := 1000
+	tests := []struct {
+		name string
+		toml string
+		want ThemeFile
+		err  string
+	}{
+		{"empty", "", ThemeFile{}, ""},
+		{"comments", "# theme\ntextSize = 12.5 # sp\n", ThemeFile{TextSize: &size}, ""},
+		{"tables", "darkMode = true\n[pallet]\nprimary = \"#6750A4\"\n[timing]\nhoverDelay = 1_000\n",
+			ThemeFile{DarkMode: &dark, Pallet: ThemeColors{Primary: "#6750A4"}, Timing: ThemeTiming{HoverDelay: &hover}}, ""},
+		{"hash in string", "[pallet]\nprimary = \"#FFF\" # white\n", ThemeFile{Pallet: ThemeColors{Primary: "#FFF"}}, ""},
+		{"padding list", "[padding]\nbutton = [1, 2, 3, 4]\n", ThemeFile{Padding: ThemePads{Button: Padding{1, 2, 3, 4}}}, ""},
+		{"padding number", "[padding]\nbutton = 5\n", ThemeFile{Padding: ThemePads{Button: Padding{5}}}, ""},
+		{"unknown key", "textColor = 1\n", ThemeFile{}, "unknown field"},
+		{"unknown table key", "[pallet]\nsurface = \"#FFF\"\n", ThemeFile{}, "unknown field"},
+		{"missing value", "textSize\n", ThemeFile{}, "line 1: expected key = value"},
+		{"nan", "textSize = nan\n", ThemeFile{}, "line 1: invalid value nan"},
+		{"inf", "textSize = +inf\n", ThemeFile{}, "line 1: invalid value +inf"},
+		{"hex number", "textSize = 0x10\n", ThemeFile{}, "line 1: invalid value 0x10"},
+		{"bad number", "\ntextSize = 1__0\n", ThemeFile{}, "line 2: invalid value 1__0"},
+		{"duplicate key", "textSize = 12\ntextSize = 14\n", ThemeFile{}, "line 2: duplicate key textSize"},
+		{"duplicate table key", "[pallet]\nprimary = \"#FFF\"\n\"primary\" = \"#000\"\n", ThemeFile{}, "line 3: duplicate key primary"},
+		{"duplicate table", "[pallet]\nprimary = \"#FFF\"\n[timing]\n[pallet]\nerror = \"#F00\"\n", ThemeFile{}, "line 4: duplicate table [pallet]"},
+		{"table after key", "pallet = 1\n[pallet]\n", ThemeFile{}, "line 2: duplicate table [pallet]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f ThemeFile
+			err := f.UnmarshalTOML([]byte(tt.toml))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(f, tt.want) {
+				t.Errorf("got %+v, want %+v", f, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{`a = 1 # comment`, `a = 1 `},
+		{`a = "#FFF" # white`, `a = "#FFF" `},
+		{`a = "x\"#" # quote`, `a = "x\"#" `},
+		{`a = "x\\" # backslash`, `a = "x\\" `},
+		{`a = "x\\\"#"`, `a = "x\\\"#"`},
+		{`# only a comment`, ``},
+		{`a = 1`, `a = 1`},
+	}
+	for _, tt := range tests {
+		if got := stripComment(tt.line); got != tt.want {
+			t.Errorf("stripComment(%s) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestThemeFileUnknownJSON(t *testing.T) {
+	var f ThemeFile
+	err := decodeStrict([]byte(`{"pallet": {"primary": "#FFF", "surface": "#000"}}`), &f)
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("got error %v, want unknown field", err)
+	}
+}
+
+func TestThemeFileValidate(t *testing.T) {
+	f32 := func(v float32) *float32 { return &v }
+	ms := func(v int) *int { return &v }
+	nan := float32(0)
+	nan /= nan
+	tests := []struct {
+		name string
+		f    ThemeFile
+		err  string
+	}{
+		{"empty", ThemeFile{}, ""},
+		{"valid", ThemeFile{TextSize: f32(14), Radius: ThemeRadius{Border: f32(0)}, Timing: ThemeTiming{Fade: ms(0)}}, ""},
+		{"small text", ThemeFile{TextSize: f32(3)}, "textSize 3 is outside 4..100"},
+		{"nan text", ThemeFile{TextSize: &nan}, "textSize NaN is outside 4..100"},
+		{"bad color", ThemeFile{Pallet: ThemeColors{Tertiary: "#12"}}, "pallet.tertiary"},
+		{"negative radius", ThemeFile{Radius: ThemeRadius{Button: f32(-1)}}, "radius.button -1 is outside 0..200"},
+		{"nan radius", ThemeFile{Radius: ThemeRadius{Scroll: &nan}}, "radius.scroll NaN is outside 0..200"},
+		{"padding count", ThemeFile{Padding: ThemePads{Inside: Padding{1, 2}}}, "padding.inside must have 1 or 4 values"},
+		{"large padding", ThemeFile{Padding: ThemePads{Icon: Padding{1, 2, 300, 4}}}, "padding.icon 300 is outside 0..200"},
+		{"negative timing", ThemeFile{Timing: ThemeTiming{WarmUp: ms(-5)}}, "timing.warmUp -5 is outside 0..60000"},
+		{"long timing", ThemeFile{Timing: ThemeTiming{Transition: ms(60001)}}, "timing.transition 60001 is outside 0..60000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.f.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+
+	"golang.org/x/exp/shiny/materialdesign/icons"
+)
+
+// TimePickerDef is an edit field for the time of day, with a spinner popup.
+type TimePickerDef struct {
+	picker
+	spinner
+	timeFormat
+	value *time.Time
+}
+
+// DurationPickerDef is an edit field for durations, with a spinner popup.
+type DurationPickerDef struct {
+	picker
+	spinner
+	timeFormat
+	value *time.Duration
+}
+
+type timeFormat struct {
+	hour12  bool
+	seconds bool
+}
+
+// TimeOption is options for time and duration pickers
+type TimeOption func(*timeFormat)
+
+// spinField is one column in the spinner, like the hours or the minutes
+type spinField struct {
+	value, min, max int
+	// wrap goes from max to min when stepping up, and from min to max when stepping down
+	wrap bool
+	// sep is drawn in front of the field
+	sep  string
+	text func(v int) string
+}
+
+// spinnerParts gives the fields of the value edited by a spinner
+type spinnerParts interface {
+	fields() []spinField
+	setField(i, v int)
+}
+
+// spinner is the popup of TimePicker and DurationPicker, with up and down
+// buttons for each field. The arrow keys select a field and change it.
+type spinner struct {
+	p     *picker
+	parts spinnerParts
+	sel   int
+	// hover is the field below the mouse, and hoverDir the button (-1, 1) or 0 for the value
+	hover    int
+	hoverDir int
+	cols     []image.Rectangle
+	arrow    int
+}
+
+var timeIcon, durationIcon, upIcon, downIcon *Icon
+
+var errTime = errors.New("time is not valid")
+
+// TimePicker returns an edit field for the time of day in value, with a spinner popup.
+// The date in value is kept, or set to today if value is zero.
+// The options Hour12() and Seconds() can be used, and base options like W() and Lbl().
+func TimePicker(th *Theme, value *time.Time, options ...Option) layout.Widget {
+	t := &TimePickerDef{value: value}
+	t.init(th, t, timeIcon, t, options)
+	t.spinner = spinner{p: &t.picker, parts: t, hover: -1}
+	t.edit.Filter = "0123456789: APMapm"
+	if t.edit.hint == "" {
+		t.edit.hint = strings.NewReplacer("15", "hh", "03", "hh", "04", "mm", "05", "ss", "PM", "AM").Replace(t.layout())
+	}
+	return t.Layout
+}
+
+// DurationPicker returns an edit field for a duration, with a spinner popup. The duration
+// is typed as hours and minutes, like 1:30, or as a Go duration, like 1h30m.
+// The option Seconds() can be used, and base options like W() and Lbl().
+func DurationPicker(th *Theme, value *time.Duration, options ...Option) layout.Widget {
+	d := &DurationPickerDef{value: value}
+	d.init(th, d, durationIcon, d, options)
+	d.spinner = spinner{p: &d.picker, parts: d, hover: -1}
+	d.edit.Filter = "0123456789:hms."
+	if d.edit.hint == "" {
+		d.edit.hint = "h:mm"
+		if d.seconds {
+			d.edit.hint = "h:mm:ss"
+		}
+	}
+	return d.Layout
+}
+
+func (o TimeOption) apply(cfg interface{}) {
+	switch c := cfg.(type) {
+	case *TimePickerDef:
+		o(&c.timeFormat)
+	case *DurationPickerDef:
+		o(&c.timeFormat)
+	}
+}
+
+// Hour12 shows the time with AM and PM
+func Hour12() TimeOption {
+	return func(f *timeFormat) {
+		f.hour12 = true
+	}
+}
+
+// Seconds adds the seconds to the time or duration
+func Seconds() TimeOption {
+	return func(f *timeFormat) {
+		f.seconds = true
+	}
+}
+
+// layout returns the time layout used to format and parse the time
+func (f timeFormat) layout() string {
+	s := "15:04"
+	if f.hour12 {
+		s = "03:04"
+	}
+	if f.seconds {
+		s += ":05"
+	}
+	if f.hour12 {
+		s += " PM"
+	}
+	return s
+}
+
+func (t *TimePickerDef) get() time.Time {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return *t.value
+}
+
+// set stores the time of day in v, keeping the date of the value
+func (t *TimePickerDef) set(h, m, s int) {
+	GuiLock.Lock()
+	old := *t.value
+	if old.IsZero() {
+		old = dateOf(time.Now())
+	}
+	*t.value = time.Date(old.Year(), old.Month(), old.Day(), h, m, s, 0, old.Location())
+	GuiLock.Unlock()
+	t.changed()
+}
+
+func (t *TimePickerDef) format() string {
+	v := t.get()
+	if v.IsZero() {
+		return ""
+	}
+	return v.Format(t.layout())
+}
+
+// mask adds the colons, and AM or PM when the digits are complete.
+// AM and PM are selected by typing a or p anywhere.
+func (t *TimePickerDef) mask(s string) string {
+	digits := "00:00"
+	if t.seconds {
+		digits += ":00"
+	}
+	m := applyMask(digits, s)
+	if !t.hour12 || len(m) < len(digits) {
+		return m
+	}
+	suffix := " AM"
+	if i := strings.LastIndexAny(s, "aApP"); i >= 0 && strings.ContainsRune("pP", rune(s[i])) {
+		suffix = " PM"
+	}
+	return m + suffix
+}
+
+func (t *TimePickerDef) store(s string) error {
+	l := t.layout()
+	if len(s) != len(l) {
+		return errIncomplete
+	}
+	v, err := time.Parse(l, s)
+	if err != nil {
+		return errTime
+	}
+	t.set(v.Hour(), v.Minute(), v.Second())
+	return nil
+}
+
+func (t *TimePickerDef) opened() {
+	t.sel = 0
+	t.hover = -1
+}
+
+func (t *TimePickerDef) fields() []spinField {
+	v := t.get()
+	f := []spinField{{value: v.Hour(), max: 23, wrap: true}}
+	if t.hour12 {
+		f[0] = spinField{value: (v.Hour()+11)%12 + 1, min: 1, max: 12, wrap: true}
+	}
+	f = append(f, spinField{value: v.Minute(), max: 59, wrap: true, sep: ":"})
+	if t.seconds {
+		f = append(f, spinField{value: v.Second(), max: 59, wrap: true, sep: ":"})
+	}
+	if t.hour12 {
+		f = append(f, spinField{value: v.Hour() / 12, max: 1, wrap: true, sep: " ",
+			text: func(v int) string { return [2]string{"AM", "PM"}[v] }})
+	}
+	return f
+}
+
+func (t *TimePickerDef) setField(i, n int) {
+	v := t.get()
+	h, m, s := v.Hour(), v.Minute(), v.Second()
+	switch {
+	case i == 0 && t.hour12:
+		h = n%12 + h/12*12
+	case i == 0:
+		h = n
+	case i == 1:
+		m = n
+	case i == 2 && t.seconds:
+		s = n
+	default:
+		// AM/PM
+		h = h%12 + n*12
+	}
+	t.set(h, m, s)
+}
+
+func (d *DurationPickerDef) get() time.Duration {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return *d.value
+}
+
+func (d *DurationPickerDef) set(v time.Duration) {
+	GuiLock.Lock()
+	*d.value = v
+	GuiLock.Unlock()
+	d.changed()
+}
+
+func (d *DurationPickerDef) format() string {
+	v := d.get()
+	h, m, s := int(v/time.Hour), int(v/time.Minute)%60, int(v/time.Second)%60
+	if d.seconds {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", h, m)
+}
+
+// mask returns s unchanged, because the number of digits in the hours can vary
+func (d *DurationPickerDef) mask(s string) string {
+	return s
+}
+
+var errDuration = errors.New("duration is not valid")
+
+// store parses h:mm, h:mm:ss or a Go duration like 1h30m
+func (d *DurationPickerDef) store(s string) error {
+	if !strings.Contains(s, ":") {
+		v, err := time.ParseDuration(s)
+		if err != nil && s != "" && isDigit(rune(s[len(s)-1])) {
+			// The unit has not been typed yet
+			return errIncomplete
+		} else if err != nil {
+			return errDuration
+		}
+		d.set(v)
+		return nil
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return errDuration
+	}
+	var v time.Duration
+	for i, p := range parts {
+		if p == "" || i > 0 && len(p) < 2 {
+			return errIncomplete
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || i > 0 && n >= 60 {
+			return errDuration
+		}
+		v = v*60 + time.Duration(n)
+	}
+	if len(parts) == 2 {
+		v *= 60
+	}
+	d.set(v * time.Second)
+	return nil
+}
+
+func (d *DurationPickerDef) opened() {
+	d.sel = 0
+	d.hover = -1
+}
+
+func (d *DurationPickerDef) fields() []spinField {
+	v := d.get()
+	f := []spinField{
+		{value: int(v / time.Hour), max: 999, text: strconv.Itoa},
+		{value: int(v/time.Minute) % 60, max: 59, wrap: true, sep: ":"},
+	}
+	if d.seconds {
+		f = append(f, spinField{value: int(v/time.Second) % 60, max: 59, wrap: true, sep: ":"})
+	}
+	return f
+}
+
+func (d *DurationPickerDef) setField(i, n int) {
+	f := d.fields()
+	f[i].value = n
+	v := time.Duration(f[0].value)*time.Hour + time.Duration(f[1].value)*time.Minute
+	if d.seconds {
+		v += time.Duration(f[2].value) * time.Second
+	}
+	// Parts of a second are kept
+	d.set(v + d.get()%time.Second)
+}
+
+// step changes field i by delta
+func (s *spinner) step(i, delta int) {
+	f := s.parts.fields()
+	if i < 0 || i >= len(f) {
+		return
+	}
+	v := f[i].value + delta
+	if n := f[i].max - f[i].min + 1; f[i].wrap {
+		v = f[i].min + ((v-f[i].min)%n+n)%n
+	} else {
+		v = Clamp(v, f[i].min, f[i].max)
+	}
+	s.parts.setField(i, v)
+}
+
+func (s *spinner) popupKeys() key.Set {
+	return "←|→|↑|↓|⇞|⇟|⏎|⌤"
+}
+
+func (s *spinner) popupKey(e key.Event) {
+	switch e.Name {
+	case key.NameLeftArrow:
+		s.sel = Max(0, s.sel-1)
+	case key.NameRightArrow:
+		s.sel = Min(len(s.parts.fields())-1, s.sel+1)
+	case key.NameUpArrow:
+		s.step(s.sel, 1)
+	case key.NameDownArrow:
+		s.step(s.sel, -1)
+	case key.NamePageUp:
+		s.step(s.sel, 10)
+	case key.NamePageDown:
+		s.step(s.sel, -10)
+	case key.NameReturn, key.NameEnter:
+		s.p.closePopup(true)
+	}
+}
+
+// hit returns the field at p, and -1 for the up button, 1 for the down button or 0 for the value
+func (s *spinner) hit(p image.Point) (int, int) {
+	for i, r := range s.cols {
+		if p.In(r) {
+			if p.Y < r.Min.Y+s.arrow {
+				return i, -1
+			} else if p.Y >= r.Max.Y-s.arrow {
+				return i, 1
+			}
+			return i, 0
+		}
+	}
+	return -1, 0
+}
+
+func (s *spinner) popupPointer(e pointer.Event) {
+	i, dir := s.hit(e.Position.Round())
+	switch e.Type {
+	case pointer.Move:
+		s.hover, s.hoverDir = i, dir
+	case pointer.Leave:
+		s.hover = -1
+	case pointer.Press:
+		if i >= 0 {
+			s.sel = i
+			// Pressing the value only selects the field
+			if dir != 0 {
+				s.step(i, -dir)
+			}
+		}
+	case pointer.Scroll:
+		if e.Scroll.Y < 0 {
+			s.step(i, 1)
+		} else if e.Scroll.Y > 0 {
+			s.step(i, -1)
+		}
+	}
+}
+
+// layoutPopup draws a column for each field, with the value between an up and a down button
+func (s *spinner) layoutPopup(gtx C) D {
+	th := s.p.th
+	fields := s.parts.fields()
+	cell := gtx.Sp(th.TextSize * 2.2)
+	pad := gtx.Dp(th.InsidePadding.Left)
+	s.arrow = cell * 3 / 4
+	colWidth, sepWidth := cell*3/2, cell/3
+	height := 2*s.arrow + cell
+	fg := th.Fg(Canvas)
+	s.cols = s.cols[:0]
+	x := pad
+	ic := gtx
+	ic.Constraints = layout.Exact(image.Pt(s.arrow, s.arrow))
+	for i, f := range fields {
+		if f.sep != "" {
+			sep := image.Rect(x, pad+s.arrow, x+sepWidth, pad+s.arrow+cell)
+			centeredText(gtx, th, sep, f.sep, fg, th.TextSize*1.4)
+			x += sepWidth
+		}
+		col := image.Rect(x, pad, x+colWidth, pad+height)
+		s.cols = append(s.cols, col)
+		value := image.Rect(col.Min.X, col.Min.Y+s.arrow, col.Max.X, col.Max.Y-s.arrow)
+		txtCol := fg
+		if i == s.sel {
+			paint.FillShape(gtx.Ops, th.Bg(PrimaryContainer), clip.UniformRRect(value, gtx.Dp(th.BorderCornerRadius)).Op(gtx.Ops))
+			txtCol = th.Fg(PrimaryContainer)
+		}
+		txt := fmt.Sprintf("%02d", f.value)
+		if f.text != nil {
+			txt = f.text(f.value)
+		}
+		centeredText(gtx, th, value, txt, txtCol, th.TextSize*1.4)
+		for _, dir := range []int{-1, 1} {
+			y, icon := col.Min.Y, upIcon
+			if dir == 1 {
+				y, icon = col.Max.Y-s.arrow, downIcon
+			}
+			btn := image.Rect(col.Min.X+(colWidth-s.arrow)/2, y, col.Min.X+(colWidth+s.arrow)/2, y+s.arrow)
+			if s.hover == i && s.hoverDir == dir {
+				paint.FillShape(gtx.Ops, MulAlpha(fg, 24), clip.Ellipse(btn).Op(gtx.Ops))
+			}
+			o := op.Offset(btn.Min).Push(gtx.Ops)
+			_ = icon.Layout(ic, fg)
+			o.Pop()
+		}
+		x += colWidth
+	}
+	return D{Size: image.Pt(x+pad, height+2*pad)}
+}
+
+func init() {
+	timeIcon, _ = NewIcon(icons.DeviceAccessTime)
+	durationIcon, _ = NewIcon(icons.ImageTimer)
+	upIcon, _ = NewIcon(icons.NavigationExpandLess)
+	downIcon, _ = NewIcon(icons.NavigationExpandMore)
+}
@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"time"
 
+	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -16,14 +17,41 @@ import (
 )
 
 const (
-	tipAreaHoverDelay        = time.Millisecond * 1200
-	tipAreaLongPressDuration = time.Millisecond * 1200
-	tipAreaFadeDuration      = time.Millisecond * 500
-	longPressDelay           = time.Millisecond * 1200
-	CursorSizeX              = 10
-	CursorSizeY              = 32
+	CursorSizeX = 10
+	CursorSizeY = 32
+	// tipHideDelay is the time the pointer has to move from the widget to an interactive tooltip
+	tipHideDelay = time.Millisecond * 300
 )
 
+var (
+	// tipsDisabled turns off all tooltips, guarded by GuiLock
+	tipsDisabled bool
+	// tipOpen is the tooltip currently shown, and tipWarm is the end of the warm-up
+	// period after it was closed. They are only used while laying out.
+	tipOpen *Tooltip
+	tipWarm time.Time
+	// tipFg is the faded text color of the tooltip whose content is drawn, used by TipContent.
+	// It is only used while laying out.
+	tipFg *color.NRGBA
+)
+
+// TipPlacement is the position of a tooltip
+type TipPlacement int
+
+const (
+	// TipAtCursor places the tooltip below and to the right of the mouse cursor
+	TipAtCursor TipPlacement = iota
+	// TipAbove, TipBelow, TipLeft and TipRight place the tooltip next to the widget.
+	// It is moved to the opposite side when there is no room in the window.
+	TipAbove
+	TipBelow
+	TipLeft
+	TipRight
+)
+
+// TipOption is options for tooltips, used by Tip() and by buttons
+type TipOption func(*Tooltip)
+
 // Tooltip implements a material design tool tip as defined at:
 // https://material.io/components/tooltips#specs
 type Tooltip struct {
@@ -36,40 +64,76 @@ type Tooltip struct {
 	Hover     InvalidateDeadline
 	Press     InvalidateDeadline
 	LongPress InvalidateDeadline
+	// Fgc and Bgc are the text and background colors. Zero values use the theme tooltip colors.
 	Fgc       color.NRGBA
 	Bgc       color.NRGBA
 	TooltipRR unit.Dp
 	TextSize  unit.Sp
-	init      bool
-	shaper    *text.Shaper
-	font      text.Font
+	// Content is shown instead of the hint text when it is set
+	Content layout.Widget
+	// Placement is the position of the tooltip relative to the widget
+	Placement TipPlacement
+	// Interactive tooltips stay open while hovered, so that they can contain links and buttons
+	Interactive bool
+	hide        InvalidateDeadline
+	tipTag      struct{}
+	tipBlock    struct{}
+	init        bool
+	shaper      *text.Shaper
+	font        text.Font
+	theme       *Theme
+	// The timings are taken from the theme when they are used, unless they are
+	// set for the tooltip by the TipDelay() and TipTiming() options
+	hoverDelay        tipTiming
+	longPressDelay    tipTiming
+	longPressDuration tipTiming
+	fadeDuration      tipTiming
+	// pointerID and pointerTime identify the last pointer event, where the pointer
+	// was at position in the widget. abs is the position of the widget in the window,
+	// when it is known.
+	pointerID   pointer.ID
+	pointerTime time.Duration
+	abs         image.Point
+	absKnown    bool
 }
 
 // MobileTooltip constructs a tooltip suitable for use on mobile devices.
 func MobileTooltip(th *Theme) Tooltip {
 	return Tooltip{
-		Fgc:      th.TooltipOnBackground,
-		Bgc:      th.TooltipBackground,
 		font:     text.Font{Weight: text.Medium},
 		shaper:   th.Shaper,
 		TextSize: th.TextSize * 0.9,
+		theme:    th,
 	}
 }
 
 // DesktopTooltip constructs a tooltip suitable for use on desktop devices.
 func DesktopTooltip(th *Theme) Tooltip {
 	return Tooltip{
-		Fgc:       th.TooltipOnBackground,
-		Bgc:       th.TooltipBackground,
 		MaxWidth:  th.TooltipWidth,
 		TooltipRR: th.TooltipCornerRadius,
 		font:      text.Font{Weight: text.Medium},
 		shaper:    th.Shaper,
 		TextSize:  th.TextSize * 0.9,
+		theme:     th,
 	}
 
 }
 
+// tipTiming is a timing set for one tooltip, used instead of the theme timing
+type tipTiming struct {
+	d   time.Duration
+	set bool
+}
+
+// or returns the timing when it is set, and otherwise the theme timing
+func (t tipTiming) or(theme time.Duration) time.Duration {
+	if t.set {
+		return t.d
+	}
+	return theme
+}
+
 // InvalidateDeadline helps to ensure that a frame is generated at a specific
 // point in time in the future. It does this by always requesting a future
 // invalidation at its target time until it reaches its target time. This
@@ -108,33 +172,216 @@ func (i *InvalidateDeadline) ClearTarget() {
 	i.Active = false
 }
 
+// Tip returns a widget that shows content as a tooltip when w is hovered or long pressed.
+// The options Placement(), Interactive() and RichTip() can be used.
+func Tip(th *Theme, w layout.Widget, content layout.Widget, options ...Option) layout.Widget {
+	t := PlatformTooltip(th)
+	t.Content = content
+	for _, option := range options {
+		option.apply(&t)
+	}
+	return func(gtx C) D {
+		return t.Layout(gtx, "", w)
+	}
+}
+
+func (o TipOption) apply(cfg interface{}) {
+	if t, ok := cfg.(interface{ tooltip() *Tooltip }); ok {
+		o(t.tooltip())
+	}
+}
+
+func (t *Tooltip) tooltip() *Tooltip {
+	return t
+}
+
+// TipDelay sets the time the mouse must hover over the widget before the tooltip is shown
+func TipDelay(hover time.Duration) TipOption {
+	return func(t *Tooltip) {
+		t.hoverDelay = tipTiming{hover, true}
+	}
+}
+
+// TipTiming overrides the theme timings for one tooltip. Zero values keep the theme setting.
+func TipTiming(hoverDelay, longPressDelay, longPressDuration, fadeDuration time.Duration) TipOption {
+	return func(t *Tooltip) {
+		if hoverDelay > 0 {
+			t.hoverDelay = tipTiming{hoverDelay, true}
+		}
+		if longPressDelay > 0 {
+			t.longPressDelay = tipTiming{longPressDelay, true}
+		}
+		if longPressDuration > 0 {
+			t.longPressDuration = tipTiming{longPressDuration, true}
+		}
+		if fadeDuration > 0 {
+			t.fadeDuration = tipTiming{fadeDuration, true}
+		}
+	}
+}
+
+// EnableTooltips turns all tooltips on or off. They are on by default.
+func EnableTooltips(on bool) {
+	GuiLock.Lock()
+	defer GuiLock.Unlock()
+	tipsDisabled = !on
+}
+
+// TooltipsEnabled returns true when tooltips are shown
+func TooltipsEnabled() bool {
+	GuiLock.RLock()
+	defer GuiLock.RUnlock()
+	return !tipsDisabled
+}
+
+// appear shows the tooltip, and makes it the open tooltip
+func (t *Tooltip) appear(gtx C) {
+	// Disappear() shortens the duration when the tooltip is closed while fading in
+	t.VisibilityAnimation.Duration = t.fadeDuration.or(t.theme.FadeDuration)
+	t.VisibilityAnimation.Appear(gtx.Now)
+	tipOpen = t
+}
+
+// disappear hides the tooltip, and starts the warm-up period when it was visible
+func (t *Tooltip) disappear(gtx C) {
+	if tipOpen == t {
+		tipOpen = nil
+		if t.Visible() {
+			tipWarm = gtx.Now.Add(t.theme.WarmUpDuration)
+		}
+	}
+	t.VisibilityAnimation.Disappear(gtx.Now)
+}
+
+// warm returns true when another tooltip is shown or was just closed, so that
+// this tooltip can be shown without delay
+func (t *Tooltip) warm(gtx C) bool {
+	if t.theme.WarmUpDuration <= 0 {
+		return false
+	}
+	return tipOpen != nil && tipOpen != t && tipOpen.Visible() || gtx.Now.Before(tipWarm)
+}
+
+// Placement sets the position of the tooltip relative to the widget
+func Placement(p TipPlacement) TipOption {
+	return func(t *Tooltip) {
+		t.Placement = p
+	}
+}
+
+// Interactive keeps the tooltip open while the mouse is over it
+func Interactive() TipOption {
+	return func(t *Tooltip) {
+		t.Interactive = true
+	}
+}
+
+// RichTip shows content as the tooltip, instead of the hint text
+func RichTip(content layout.Widget) TipOption {
+	return func(t *Tooltip) {
+		t.Content = content
+	}
+}
+
+// TipContent returns tooltip content with an icon, a bold title with a keyboard shortcut,
+// and a body text that is wrapped. Empty parts are left out.
+func TipContent(th *Theme, icon *Icon, title, body string, keys key.Set) layout.Widget {
+	return func(gtx C) D {
+		fg := th.TooltipOnBackground
+		if tipFg != nil {
+			// Drawn in a tooltip, that may be fading in or out
+			fg = *tipFg
+		}
+		size := th.TextSize * 0.9
+		gap := gtx.Sp(size * 0.5)
+		x := 0
+		if icon != nil {
+			s := gtx.Sp(size * 1.6)
+			c := gtx
+			c.Constraints = layout.Exact(image.Pt(s, s))
+			_ = icon.Layout(c, fg)
+			x = s + gap
+		}
+		defer op.Offset(image.Pt(x, 0)).Push(gtx.Ops).Pop()
+		c := gtx
+		c.Constraints.Min = image.Point{}
+		c.Constraints.Max.X = Max(0, c.Constraints.Max.X-x)
+		width, y := 0, 0
+		if title != "" || keys != "" {
+			font := th.DefaultFont
+			font.Weight = text.Bold
+			paint.ColorOp{Color: fg}.Add(gtx.Ops)
+			dims := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, font, size, title)
+			width, y = dims.Size.X, dims.Size.Y
+			if keys != "" {
+				o := op.Offset(image.Pt(width+2*gap, 0)).Push(gtx.Ops)
+				paint.ColorOp{Color: MulAlpha(fg, 160)}.Add(gtx.Ops)
+				k := widget.Label{MaxLines: 1}.Layout(c, th.Shaper, th.DefaultFont, size, KeyText(keys))
+				o.Pop()
+				width += 2*gap + k.Size.X
+			}
+		}
+		if body != "" {
+			if y > 0 {
+				y += gap / 2
+			}
+			o := op.Offset(image.Pt(0, y)).Push(gtx.Ops)
+			paint.ColorOp{Color: fg}.Add(gtx.Ops)
+			dims := widget.Label{}.Layout(c, th.Shaper, th.DefaultFont, size, body)
+			o.Pop()
+			width, y = Max(width, dims.Size.X), y+dims.Size.Y
+		}
+		return D{Size: image.Pt(x+width, Max(y, gtx.Sp(size*1.6)))}
+	}
+}
+
 // Layout renders the provided widget with the provided tooltip. The tooltip
-// will be summoned if the widget is hovered or long-pressed.
+// will be summoned if the widget is hovered or long-pressed. The Content of the
+// tooltip is shown instead of the hint when it is set.
 func (t *Tooltip) Layout(gtx C, hint string, w layout.Widget) D {
-	if hint == "" {
+	if hint == "" && t.Content == nil || !TooltipsEnabled() {
+		if t.Visible() {
+			t.disappear(gtx)
+			t.VisibilityAnimation.State = Invisible
+		}
+		t.Hover.ClearTarget()
+		t.Press.ClearTarget()
 		return w(gtx)
 	}
 	if !t.init {
 		t.init = true
 		t.VisibilityAnimation.State = Invisible
-		t.VisibilityAnimation.Duration = tipAreaFadeDuration
+		t.VisibilityAnimation.Duration = t.fadeDuration.or(t.theme.FadeDuration)
+		if t.VisibilityAnimation.Easing == nil {
+			t.VisibilityAnimation.Easing = EaseOut
+		}
 	}
 	for _, e := range gtx.Events(t) {
 		e, ok := e.(pointer.Event)
 		if !ok {
 			continue
 		}
-		t.position.X = int(e.Position.X)
-		t.position.Y = int(e.Position.Y)
+		t.position = e.Position.Round()
+		t.pointerID, t.pointerTime = e.PointerID, e.Time
 		switch e.Type {
 		case pointer.Enter:
-			t.Hover.SetTarget(gtx.Now.Add(tipAreaHoverDelay))
+			t.hide.ClearTarget()
+			if t.warm(gtx) {
+				t.appear(gtx)
+			} else if !t.Visible() {
+				t.Hover.SetTarget(gtx.Now.Add(t.hoverDelay.or(t.theme.HoverDelay)))
+			}
 		case pointer.Leave:
-			t.VisibilityAnimation.Disappear(gtx.Now)
+			if t.Interactive && t.Visible() {
+				// Give the user time to move the pointer into the tooltip
+				t.hide.SetTarget(gtx.Now.Add(tipHideDelay))
+			} else {
+				t.disappear(gtx)
+			}
 			t.Hover.ClearTarget()
 		case pointer.Press:
 			t.Hover.ClearTarget()
-			t.Press.SetTarget(gtx.Now.Add(longPressDelay))
+			t.Press.SetTarget(gtx.Now.Add(t.longPressDelay.or(t.theme.LongPressDelay)))
 		case pointer.Release:
 			t.Hover.ClearTarget()
 			t.Press.ClearTarget()
@@ -143,22 +390,42 @@ func (t *Tooltip) Layout(gtx C, hint string, w layout.Widget) D {
 			t.Press.ClearTarget()
 		}
 	}
+	for _, e := range gtx.Events(&t.tipTag) {
+		if e, ok := e.(pointer.Event); ok {
+			switch e.Type {
+			case pointer.Enter:
+				t.hide.ClearTarget()
+			case pointer.Leave, pointer.Cancel:
+				t.hide.SetTarget(gtx.Now.Add(tipHideDelay))
+			}
+		}
+	}
 	if t.Hover.Process(gtx) {
-		t.VisibilityAnimation.Appear(gtx.Now)
+		t.appear(gtx)
 	}
 	if t.Press.Process(gtx) {
-		t.VisibilityAnimation.Appear(gtx.Now)
-		t.LongPress.SetTarget(gtx.Now.Add(tipAreaLongPressDuration))
+		t.appear(gtx)
+		t.LongPress.SetTarget(gtx.Now.Add(t.longPressDuration.or(t.theme.LongPressDuration)))
 	}
 	if t.LongPress.Process(gtx) {
-		t.VisibilityAnimation.Disappear(gtx.Now)
+		t.disappear(gtx)
+	}
+	if t.hide.Process(gtx) {
+		t.disappear(gtx)
+	}
+	content := t.Content
+	if content == nil {
+		content = func(gtx C) D {
+			paint.ColorOp{Color: *tipFg}.Add(gtx.Ops)
+			return t.Text.Layout(gtx, t.shaper, t.font, t.TextSize, hint)
+		}
 	}
 	return layout.Stack{}.Layout(gtx,
 		layout.Stacked(w),
 		layout.Expanded(func(gtx C) D {
 			defer pointer.PassOp{}.Push(gtx.Ops).Pop()
-			rect := image.Rectangle{Max: gtx.Constraints.Min}
-			r := clip.Rect(rect).Push(gtx.Ops)
+			anchor := gtx.Constraints.Min
+			r := clip.Rect(image.Rectangle{Max: anchor}).Push(gtx.Ops)
 			pointer.InputOp{
 				Tag:   t,
 				Types: pointer.Press | pointer.Release | pointer.Enter | pointer.Leave | pointer.Move,
@@ -168,9 +435,17 @@ func (t *Tooltip) Layout(gtx C, hint string, w layout.Widget) D {
 			if t.Visible() {
 				macro := op.Record(gtx.Ops)
 				v := t.VisibilityAnimation.Revealed(gtx)
-				bg := WithAlpha(t.Bgc, uint8(v*255))
-				t.Fgc = WithAlpha(t.Fgc, uint8(v*255))
-				gtx.Constraints.Max.X = gtx.Metric.Dp(t.MaxWidth)
+				fg, bg := t.Fgc, t.Bgc
+				if fg == (color.NRGBA{}) {
+					fg = t.theme.TooltipOnBackground
+				}
+				if bg == (color.NRGBA{}) {
+					bg = t.theme.TooltipBackground
+				}
+				fg, bg = WithAlpha(fg, uint8(v*255)), WithAlpha(bg, uint8(v*255))
+				if t.MaxWidth > 0 {
+					gtx.Constraints.Max.X = gtx.Metric.Dp(t.MaxWidth)
+				}
 				p := unit.Dp(t.TextSize * 0.5)
 				inset := layout.Inset{Top: p, Right: p, Bottom: p, Left: p}
 				dims := layout.Stack{}.Layout(
@@ -179,28 +454,33 @@ func (t *Tooltip) Layout(gtx C, hint string, w layout.Widget) D {
 						rr := gtx.Dp(t.TooltipRR)
 						outline := image.Rectangle{Max: gtx.Constraints.Min}
 						paint.FillShape(gtx.Ops, bg, clip.UniformRRect(outline, rr).Op(gtx.Ops))
-						paintBorder(gtx, outline, MulAlpha(t.Fgc, 128), unit.Dp(0.5), gtx.Dp(t.TooltipRR))
+						paintBorder(gtx, outline, MulAlpha(fg, 128), unit.Dp(0.5), gtx.Dp(t.TooltipRR))
 						return D{}
 					}),
 					layout.Stacked(func(gtx C) D {
-						return inset.Layout(gtx, func(gtx C) D {
-							paint.ColorOp{Color: t.Fgc}.Add(gtx.Ops)
-							return t.Text.Layout(gtx, t.shaper, t.font, t.TextSize, hint)
-						})
+						tipFg = &fg
+						defer func() { tipFg = nil }()
+						return inset.Layout(gtx, content)
 					}),
 				)
-				dx := int(MouseX) + CursorSizeX + dims.Size.X - WinX
-				if dx < 0 {
-					dx = 0
-				}
-				dy := int(MouseY) + CursorSizeY + dims.Size.Y - WinY
-				if dy < 0 {
-					dy = 0
-				}
 				call := macro.Stop()
 				macro = op.Record(gtx.Ops)
-				op.Offset(t.position.Add(image.Pt(-dx+CursorSizeX, -dy+CursorSizeY))).Add(gtx.Ops)
+				op.Offset(t.place(gtx, anchor, dims.Size)).Add(gtx.Ops)
+				tip := image.Rectangle{Max: dims.Size}
+				if t.Interactive {
+					// The tooltip is not transparent for the pointer
+					r := clip.Rect(tip).Push(gtx.Ops)
+					pointer.InputOp{Tag: &t.tipBlock, Types: pointer.Press}.Add(gtx.Ops)
+					r.Pop()
+				}
 				call.Add(gtx.Ops)
+				if t.Interactive {
+					r := clip.Rect(tip).Push(gtx.Ops)
+					pass := pointer.PassOp{}.Push(gtx.Ops)
+					pointer.InputOp{Tag: &t.tipTag, Types: pointer.Enter | pointer.Leave}.Add(gtx.Ops)
+					pass.Pop()
+					r.Pop()
+				}
 				call = macro.Stop()
 				op.Defer(gtx.Ops, call)
 			}
@@ -208,3 +488,50 @@ func (t *Tooltip) Layout(gtx C, hint string, w layout.Widget) D {
 		}),
 	)
 }
+
+// place returns the position of a tooltip of the given size, relative to the widget
+// with size anchor. The tooltip is kept inside the window when the position of the
+// widget in the window is known.
+func (t *Tooltip) place(gtx C, anchor, size image.Point) image.Point {
+	if p, ok := windowPos(t.pointerID, t.pointerTime); ok {
+		t.abs, t.absKnown = p.Sub(t.position), true
+	}
+	abs := t.abs
+	if t.Placement == TipAtCursor {
+		pos := t.position.Add(image.Pt(CursorSizeX, CursorSizeY))
+		if t.absKnown {
+			pos.X -= Max(0, abs.X+pos.X+size.X-WinX)
+			pos.Y -= Max(0, abs.Y+pos.Y+size.Y-WinY)
+		}
+		return pos
+	}
+	gap := gtx.Dp(4)
+	placement := t.Placement
+	switch {
+	case !t.absKnown:
+	case placement == TipAbove && abs.Y-gap-size.Y < 0 && abs.Y+anchor.Y+gap+size.Y <= WinY:
+		placement = TipBelow
+	case placement == TipBelow && abs.Y+anchor.Y+gap+size.Y > WinY && abs.Y-gap-size.Y >= 0:
+		placement = TipAbove
+	case placement == TipLeft && abs.X-gap-size.X < 0 && abs.X+anchor.X+gap+size.X <= WinX:
+		placement = TipRight
+	case placement == TipRight && abs.X+anchor.X+gap+size.X > WinX && abs.X-gap-size.X >= 0:
+		placement = TipLeft
+	}
+	var pos image.Point
+	switch placement {
+	case TipAbove:
+		pos = image.Pt((anchor.X-size.X)/2, -gap-size.Y)
+	case TipBelow:
+		pos = image.Pt((anchor.X-size.X)/2, anchor.Y+gap)
+	case TipLeft:
+		pos = image.Pt(-gap-size.X, (anchor.Y-size.Y)/2)
+	case TipRight:
+		pos = image.Pt(anchor.X+gap, (anchor.Y-size.Y)/2)
+	}
+	if t.absKnown {
+		pos.X = Clamp(pos.X, -abs.X, Max(-abs.X, WinX-abs.X-size.X))
+		pos.Y = Clamp(pos.Y, -abs.Y, Max(-abs.Y, WinY-abs.Y-size.Y))
+	}
+	return pos
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package wid
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+func TestTooltipPlace(t *testing.T) {
+	WinX, WinY = 400, 300
+	gtx := layout.Context{Metric: unit.Metric{PxPerDp: 1, PxPerSp: 1}}
+	anchor, size := image.Pt(40, 20), image.Pt(100, 30)
+	tests := []struct {
+		name      string
+		placement TipPlacement
+		// widget is the position of the widget in the window, and logged is false
+		// when the window position of the pointer event is not known
+		widget image.Point
+		logged bool
+		want   image.Point
+	}{
+		{"below", TipBelow, image.Pt(150, 100), true, image.Pt(-30, 24)},
+		{"above", TipAbove, image.Pt(150, 100), true, image.Pt(-30, -34)},
+		{"above flips", TipAbove, image.Pt(150, 10), true, image.Pt(-30, 24)},
+		{"below flips", TipBelow, image.Pt(150, 270), true, image.Pt(-30, -34)},
+		{"right flips", TipRight, image.Pt(350, 100), true, image.Pt(-104, -5)},
+		{"kept in window", TipBelow, image.Pt(0, 100), true, image.Pt(0, 24)},
+		{"cursor", TipAtCursor, image.Pt(150, 100), true, image.Pt(15, 39)},
+		{"cursor kept in window", TipAtCursor, image.Pt(350, 100), true, image.Pt(-50, 39)},
+		{"unknown position", TipAbove, image.Pt(150, 10), false, image.Pt(-30, -34)},
+	}
+	for i, tt := range tests {
+		tip := Tooltip{Placement: tt.placement}
+		// A touch press in the widget, with a stale mouse position
+		MouseX, MouseY = 0, 0
+		e := pointer.Event{Type: pointer.Press, PointerID: pointer.ID(i), Time: time.Duration(i+1) * time.Second, Position: f32.Pt(5, 7)}
+		tip.position = e.Position.Round()
+		tip.pointerID, tip.pointerTime = e.PointerID, e.Time
+		if tt.logged {
+			w := e
+			w.Position = e.Position.Add(layout.FPt(tt.widget))
+			logPointer(w)
+		}
+		if got := tip.place(gtx, anchor, size); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTooltipTiming(t *testing.T) {
+	gtx := layout.Context{Now: time.Unix(1000, 0)}
+	th := &Theme{FadeDuration: 100 * time.Millisecond, HoverDelay: 700 * time.Millisecond}
+	tip := DesktopTooltip(th)
+	fixed := DesktopTooltip(th)
+	TipTiming(0, 0, 0, 50*time.Millisecond)(&fixed)
+	// The theme is changed after the tooltips are made
+	th.FadeDuration = 300 * time.Millisecond
+	tip.appear(gtx)
+	fixed.appear(gtx)
+	if tip.Duration != 300*time.Millisecond || fixed.Duration != 50*time.Millisecond {
+		t.Errorf("got fade %v and %v, want 300ms and 50ms", tip.Duration, fixed.Duration)
+	}
+	tip.disappear(gtx)
+	fixed.disappear(gtx)
+	if got := tip.hoverDelay.or(th.HoverDelay); got != th.HoverDelay {
+		t.Errorf("hover delay %v is not the theme delay", got)
+	}
+	TipDelay(0)(&tip)
+	if got := tip.hoverDelay.or(th.HoverDelay); got != 0 {
+		t.Errorf("hover delay %v is not the tooltip delay", got)
+	}
+}